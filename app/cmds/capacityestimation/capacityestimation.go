@@ -17,6 +17,7 @@ limitations under the License.
 package capacityestimation
 
 import (
+	"context"
 	"errors"
 	"flag"
 	"fmt"
@@ -29,11 +30,12 @@ import (
 	"github.com/k-cloud-labs/kluster-capacity/app/cmds/capacityestimation/options"
 	"github.com/k-cloud-labs/kluster-capacity/pkg"
 	"github.com/k-cloud-labs/kluster-capacity/pkg/simulator/capacityestimation"
+	"github.com/k-cloud-labs/kluster-capacity/pkg/snapshot"
 )
 
 var capacityEstimationLong = dedent.Dedent(`
 		ce simulates an API server with initial state copied from the Kubernetes environment
-		with its configuration specified in KUBECONFIG. The simulated API server tries to schedule the number of
+		with its configuration specified in KUBECONFIG, or loaded from the snapshot specified by --snapshot flag. The simulated API server tries to schedule the number of
 		pods specified by --max-limits flag. If the --max-limits flag is not specified, pods are scheduled until
 		the simulated API server runs out of resources.
 	`)
@@ -81,23 +83,48 @@ func validate(opt *options.CapacityEstimationOptions) error {
 		return errors.New("pod template file and pod from cluster is exclusive")
 	}
 
-	if len(opt.KubeConfig) == 0 {
-		return errors.New("kubeconfig is missing")
+	if err := opt.ValidateSource(); err != nil {
+		return err
 	}
 
-	return nil
+	if _, err := opt.ScopeOptions(); err != nil {
+		return err
+	}
+
+	return opt.ValidateFramework()
 }
 
 func run(opt *options.CapacityEstimationOptions) error {
 	defer klog.Flush()
 	conf := options.NewCapacityEstimationConfig(opt)
 
+	if len(opt.Snapshot) > 0 {
+		objs, err := snapshot.LoadObjects(opt.Snapshot)
+		if err != nil {
+			return err
+		}
+		conf.InitObjs = objs
+	}
+
 	err := conf.ParseAPISpec()
 	if err != nil {
 		return fmt.Errorf("failed to parse pod spec file: %v ", err)
 	}
 
-	reports, err := runSimulator(conf)
+	closeTrace, err := opt.OpenTrace()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := closeTrace(); err != nil {
+			klog.ErrorS(err, "Failed to close trace file")
+		}
+	}()
+
+	ctx, cancel := opt.Context()
+	defer cancel()
+
+	reports, err := runSimulator(ctx, conf)
 	if err != nil {
 		return err
 	}
@@ -109,21 +136,11 @@ func run(opt *options.CapacityEstimationOptions) error {
 	return nil
 }
 
-func runSimulator(conf *options.CapacityEstimationConfig) (pkg.Printer, error) {
-	s, err := capacityestimation.NewCESimulatorExecutor(conf)
-	if err != nil {
-		return nil, err
-	}
-
-	err = s.Initialize(conf.InitObjs...)
-	if err != nil {
-		return nil, err
-	}
-
-	err = s.Run()
+func runSimulator(ctx context.Context, conf *options.CapacityEstimationConfig) (pkg.Printer, error) {
+	simulatorConfig, err := conf.SimulatorConfig()
 	if err != nil {
 		return nil, err
 	}
 
-	return s.Report(), nil
+	return capacityestimation.Estimate(ctx, simulatorConfig)
 }
@@ -3,20 +3,15 @@ package options
 import (
 	"context"
 	"fmt"
-	"io"
-	"net/http"
-	"os"
-	"path/filepath"
-	"strings"
 
 	"github.com/spf13/pflag"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/util/yaml"
 	clientset "k8s.io/client-go/kubernetes"
 
 	"github.com/k-cloud-labs/kluster-capacity/app/cmds"
+	"github.com/k-cloud-labs/kluster-capacity/pkg/simulator/capacityestimation"
 	"github.com/k-cloud-labs/kluster-capacity/pkg/utils"
 )
 
@@ -24,6 +19,10 @@ type CapacityEstimationOptions struct {
 	cmds.Options
 	PodsFromTemplate []string
 	PodsFromCluster  NamespaceNames
+	// preempt lower priority pods if the pod could not be scheduled in free space
+	EnablePreemption bool
+	// max number of pod templates simulated at the same time, unlimited if not positive
+	Parallelism int
 }
 
 type CapacityEstimationConfig struct {
@@ -51,46 +50,40 @@ func (s *CapacityEstimationOptions) AddFlags(fs *pflag.FlagSet) {
 	fs.BoolVar(&s.Verbose, "verbose", s.Verbose, "Verbose mode")
 	fs.StringVarP(&s.OutputFormat, "output", "o", s.OutputFormat, "Output format. One of: json|yaml (Note: output is not versioned or guaranteed to be stable across releases)")
 	fs.StringSliceVar(&s.ExcludeNodes, "exclude-nodes", s.ExcludeNodes, "Exclude nodes to be scheduled")
+	fs.BoolVar(&s.EnablePreemption, "enable-preemption", s.EnablePreemption, "Preempt lower priority pods when the pod could not be scheduled in free space, the victims are reported for each replica")
+	fs.StringVar(&s.Snapshot, "snapshot", s.Snapshot, "Path of snapshot to initialize the world instead of copying from the cluster, --pods-from-cluster is also looked up from it")
+	s.AddCommonFlags(fs)
+	fs.StringVar(&s.Trace, "trace", s.Trace, "File to write the filter and score results of each scheduled pod as NDJSON")
+	fs.IntVar(&s.Parallelism, "parallelism", s.Parallelism, "Max number of pod templates simulated at the same time. By default unlimited")
 }
 
-func (s *CapacityEstimationConfig) ParseAPISpec() error {
-	getPodFromTemplate := func(template string) (*corev1.Pod, error) {
-		var (
-			err          error
-			versionedPod = &corev1.Pod{}
-			spec         io.Reader
-		)
-
-		if strings.HasPrefix(template, "http://") || strings.HasPrefix(template, "https://") {
-			response, err := http.Get(template)
-			if err != nil {
-				return nil, err
-			}
-			defer response.Body.Close()
-			if response.StatusCode != http.StatusOK {
-				return nil, fmt.Errorf("unable to read URL %q, server reported %v, status code=%v", template, response.Status, response.StatusCode)
-			}
-			spec = response.Body
-		} else {
-			filename, _ := filepath.Abs(template)
-			spec, err = os.Open(filename)
-			if err != nil {
-				return nil, fmt.Errorf("failed to open config file: %v", err)
-			}
-		}
-
-		decoder := yaml.NewYAMLOrJSONDecoder(spec, 4096)
-		err = decoder.Decode(versionedPod)
-		if err != nil {
-			return nil, fmt.Errorf("failed to decode config file: %v", err)
-		}
-
-		return versionedPod, nil
+// SimulatorConfig converts the config to the config of the ce simulator, pods must be parsed before.
+func (s *CapacityEstimationConfig) SimulatorConfig() (*capacityestimation.Config, error) {
+	options, err := s.Options.SimulatorOptions(s.InitObjs)
+	if err != nil {
+		return nil, err
 	}
 
+	return &capacityestimation.Config{
+		Options:          options,
+		Pods:             s.Pods,
+		EnablePreemption: s.Options.EnablePreemption,
+		Parallelism:      s.Options.Parallelism,
+	}, nil
+}
+
+func (s *CapacityEstimationConfig) ParseAPISpec() error {
 	if len(s.Options.PodsFromTemplate) != 0 {
 		for _, template := range s.Options.PodsFromTemplate {
-			pod, err := getPodFromTemplate(template)
+			pod, err := utils.LoadPodTemplate(template)
+			if err != nil {
+				return err
+			}
+			s.Pods = append(s.Pods, pod)
+		}
+	} else if len(s.Options.Snapshot) > 0 {
+		for _, nn := range s.Options.PodsFromCluster {
+			pod, err := s.getPodFromInitObjs(nn)
 			if err != nil {
 				return err
 			}
@@ -118,3 +111,13 @@ func (s *CapacityEstimationConfig) ParseAPISpec() error {
 
 	return nil
 }
+
+func (s *CapacityEstimationConfig) getPodFromInitObjs(nn NamespaceName) (*corev1.Pod, error) {
+	for _, obj := range s.InitObjs {
+		if pod, ok := obj.(*corev1.Pod); ok && pod.Namespace == nn.Namespace && pod.Name == nn.Name {
+			return pod.DeepCopy(), nil
+		}
+	}
+
+	return nil, fmt.Errorf("pod %s/%s not found in snapshot", nn.Namespace, nn.Name)
+}
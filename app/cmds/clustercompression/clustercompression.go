@@ -17,7 +17,7 @@ limitations under the License.
 package clustercompression
 
 import (
-	"errors"
+	"context"
 	"flag"
 	"fmt"
 
@@ -29,11 +29,12 @@ import (
 	"github.com/k-cloud-labs/kluster-capacity/app/cmds/clustercompression/options"
 	"github.com/k-cloud-labs/kluster-capacity/pkg"
 	"github.com/k-cloud-labs/kluster-capacity/pkg/simulator/clustercompression"
+	"github.com/k-cloud-labs/kluster-capacity/pkg/snapshot"
 )
 
 var clusterCompressionLong = dedent.Dedent(`
 	The "cc" tool simulates an API server by copying the initial state from the Kubernetes environment, 
-	using the configuration specified in KUBECONFIG. It attempts to scale down the number of nodes to 
+	using the configuration specified in KUBECONFIG, or from the snapshot specified by --snapshot flag. It attempts to scale down the number of nodes to 
 	the limit specified by the --max-limits flag, and if this flag is not provided, it schedules pods 
 	onto as few nodes as possible and provides a list of nodes that can be taken offline.
 	`)
@@ -73,18 +74,47 @@ func NewClusterCompressionCmd() *cobra.Command {
 }
 
 func validateOptions(opt *options.ClusterCompressionOptions) error {
-	if len(opt.KubeConfig) == 0 {
-		return errors.New("kubeconfig is missing")
+	if err := opt.ValidateSource(); err != nil {
+		return err
 	}
 
-	return nil
+	if _, err := opt.ScopeOptions(); err != nil {
+		return err
+	}
+
+	if _, err := opt.NodeOrderOptions(); err != nil {
+		return err
+	}
+
+	return opt.ValidateFramework()
 }
 
 func run(opt *options.ClusterCompressionOptions) error {
 	defer klog.Flush()
 	conf := options.NewClusterCompressionConfig(opt)
 
-	reports, err := runCCSimulator(conf)
+	if len(opt.Snapshot) > 0 {
+		objs, err := snapshot.LoadObjects(opt.Snapshot)
+		if err != nil {
+			return err
+		}
+		conf.InitObjs = objs
+	}
+
+	closeTrace, err := opt.OpenTrace()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := closeTrace(); err != nil {
+			klog.ErrorS(err, "Failed to close trace file")
+		}
+	}()
+
+	ctx, cancel := opt.Context()
+	defer cancel()
+
+	reports, err := runCCSimulator(ctx, conf)
 	if err != nil {
 		klog.Errorf("runCCSimulator err: %s\n", err.Error())
 		return err
@@ -96,21 +126,11 @@ func run(opt *options.ClusterCompressionOptions) error {
 	return nil
 }
 
-func runCCSimulator(conf *options.ClusterCompressionConfig) (pkg.Printer, error) {
-	s, err := clustercompression.NewCCSimulatorExecutor(conf)
-	if err != nil {
-		return nil, err
-	}
-
-	err = s.Initialize()
-	if err != nil {
-		return nil, err
-	}
-
-	err = s.Run()
+func runCCSimulator(ctx context.Context, conf *options.ClusterCompressionConfig) (pkg.Printer, error) {
+	simulatorConfig, err := conf.SimulatorConfig()
 	if err != nil {
 		return nil, err
 	}
 
-	return s.Report(), nil
+	return clustercompression.Compress(ctx, simulatorConfig)
 }
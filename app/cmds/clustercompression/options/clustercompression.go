@@ -1,27 +1,32 @@
 package options
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
+
 	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/runtime"
 
 	"github.com/k-cloud-labs/kluster-capacity/app/cmds"
+	"github.com/k-cloud-labs/kluster-capacity/pkg/simulator/clustercompression"
 )
 
 type ClusterCompressionOptions struct {
 	cmds.Options
-	FilterNodeOptions FilterNodeOptions
-}
-
-type FilterNodeOptions struct {
-	ExcludeNotReadyNode bool
-	ExcludeTaintNode    bool
-	IgnoreStaticPod     bool
-	IgnoreMirrorPod     bool
-	IgnoreCloneSet      bool
-	IgnoreVolumePod     bool
+	FilterNodeOptions clustercompression.FilterNodeOptions
+	// label of nodes to compress and report them group by group, e.g. node pools
+	GroupByLabel string
+	// order of the candidate nodes, one of least-requested|fewest-pods|most-expensive|random
+	NodeOrder string
+	// price of nodes keyed by the value of PriceLabel or the node name
+	NodePrices map[string]string
+	PriceLabel string
 }
 
 type ClusterCompressionConfig struct {
-	Options *ClusterCompressionOptions
+	InitObjs []runtime.Object
+	Options  *ClusterCompressionOptions
 }
 
 func NewClusterCompressionConfig(opt *ClusterCompressionOptions) *ClusterCompressionConfig {
@@ -30,6 +35,54 @@ func NewClusterCompressionConfig(opt *ClusterCompressionOptions) *ClusterCompres
 	}
 }
 
+// SimulatorConfig converts the config to the config of the cc simulator.
+func (s *ClusterCompressionConfig) SimulatorConfig() (*clustercompression.Config, error) {
+	options, err := s.Options.SimulatorOptions(s.InitObjs)
+	if err != nil {
+		return nil, err
+	}
+
+	nodeOrderOptions, err := s.Options.NodeOrderOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	return &clustercompression.Config{
+		Options:           options,
+		FilterNodeOptions: s.Options.FilterNodeOptions,
+		GroupByLabel:      s.Options.GroupByLabel,
+		NodeOrderOptions:  nodeOrderOptions,
+	}, nil
+}
+
+// NodeOrderOptions parses the prices of nodes, it fails if the order is not supported.
+func (s *ClusterCompressionOptions) NodeOrderOptions() (clustercompression.NodeOrderOptions, error) {
+	options := clustercompression.NodeOrderOptions{
+		Order:      s.NodeOrder,
+		PriceLabel: s.PriceLabel,
+	}
+	if len(s.NodePrices) > 0 {
+		options.Prices = make(map[string]float64, len(s.NodePrices))
+		for key, value := range s.NodePrices {
+			price, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return options, fmt.Errorf("invalid price %s of %s: %v", value, key, err)
+			}
+			options.Prices[key] = price
+		}
+	}
+
+	if _, err := clustercompression.NewNodeOrderer(options, nil, 0); err != nil {
+		return options, err
+	}
+
+	if options.Order == clustercompression.NodeOrderMostExpensive && len(options.Prices) == 0 {
+		return options, fmt.Errorf("node prices are required by node order %s", options.Order)
+	}
+
+	return options, nil
+}
+
 func NewClusterCompressionOptions() *ClusterCompressionOptions {
 	return &ClusterCompressionOptions{}
 }
@@ -45,6 +98,14 @@ func (s *ClusterCompressionOptions) AddFlags(fs *pflag.FlagSet) {
 	fs.BoolVar(&s.FilterNodeOptions.IgnoreMirrorPod, "ignore-mirror-pod", false, "Whether to ignore nodes with mirror pods when filtering nodes. By default false.")
 	fs.BoolVar(&s.FilterNodeOptions.IgnoreCloneSet, "ignore-cloneset", false, "Whether to ignore nodes with cloneSet pods when filtering nodes. By default false.")
 	fs.BoolVar(&s.FilterNodeOptions.IgnoreVolumePod, "ignore-volume-pod", false, "Whether to ignore nodes with volume pods when filtering nodes. By default false.")
+	fs.BoolVar(&s.FilterNodeOptions.ClusterAutoscalerCompatible, "cluster-autoscaler-compatible", false, "Whether to apply the same rules as cluster-autoscaler when filtering nodes, i.e. nodes annotated with cluster-autoscaler.kubernetes.io/scale-down-disabled=true and nodes with pods annotated with cluster-autoscaler.kubernetes.io/safe-to-evict=false, pods without controller, pods in kube-system without PodDisruptionBudget or pods using hostPath or non-memory emptyDir volumes not listed in cluster-autoscaler.kubernetes.io/safe-to-evict-local-volumes are not scaled down, and the blocking pods are reported. By default false.")
+	fs.StringVar(&s.GroupByLabel, "group-by-label", s.GroupByLabel, "Label of nodes to group them, e.g. node.kubernetes.io/instance-type or the node group label of the autoscaler. The nodes are scaled down group by group and the result of each group is reported")
+	fs.StringVar(&s.NodeOrder, "node-order", s.NodeOrder, fmt.Sprintf("Order to try the candidate nodes. One of: %s. By default the order they are listed", strings.Join(clustercompression.NodeOrders(), "|")))
+	fs.StringToStringVar(&s.NodePrices, "node-prices", s.NodePrices, "Price of nodes used by --node-order most-expensive, keyed by the value of --price-label of nodes or node names, e.g. m5.large=0.096,m5.xlarge=0.192")
+	fs.StringVar(&s.PriceLabel, "price-label", clustercompression.DefaultPriceLabel, "Label of nodes to look up the price in --node-prices")
 	fs.StringSliceVar(&s.ExcludeNodes, "exclude-nodes", s.ExcludeNodes, "Exclude nodes to be scheduled")
 	fs.BoolVar(&s.Verbose, "verbose", s.Verbose, "Verbose mode")
+	fs.StringVar(&s.Snapshot, "snapshot", s.Snapshot, "Path of snapshot to initialize the world instead of copying from the cluster.")
+	s.AddCommonFlags(fs)
+	fs.StringVar(&s.Trace, "trace", s.Trace, "File to write the filter and score results of each scheduled pod as NDJSON")
 }
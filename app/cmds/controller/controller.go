@@ -0,0 +1,150 @@
+/*
+Copyright © 2023 k-cloud-labs org
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"flag"
+
+	"github.com/lithammer/dedent"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	cliflag "k8s.io/component-base/cli/flag"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/manager/signals"
+
+	"github.com/k-cloud-labs/kluster-capacity/app/cmds/controller/options"
+	"github.com/k-cloud-labs/kluster-capacity/pkg/apis/v1alpha1"
+	pkgcontroller "github.com/k-cloud-labs/kluster-capacity/pkg/controller"
+	"github.com/k-cloud-labs/kluster-capacity/pkg/utils"
+)
+
+var controllerLong = dedent.Dedent(`
+		controller watches CapacityEstimationReview and ClusterCompressionReview, runs the simulation when
+		a review is created, its spec is changed or its interval is reached, and writes the result into
+		the status of the review. The CRDs must be installed first, e.g. kubectl apply -f config/crd.
+	`)
+
+func NewControllerCmd() *cobra.Command {
+	opt := options.NewControllerOptions()
+
+	var cmd = &cobra.Command{
+		Use:           "controller",
+		Short:         "controller reconciles the review CRDs so that analyses could be requested by kubectl",
+		Long:          controllerLong,
+		SilenceErrors: false,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			flag.Parse()
+
+			opt.Default()
+			err := validateOptions(opt)
+			if err != nil {
+				return err
+			}
+
+			err = run(opt)
+			if err != nil {
+				return err
+			}
+
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.SetNormalizeFunc(cliflag.WordSepNormalizeFunc)
+	flags.AddGoFlagSet(flag.CommandLine)
+	opt.AddFlags(flags)
+
+	return cmd
+}
+
+func validateOptions(opt *options.ControllerOptions) error {
+	if err := opt.ValidateFramework(); err != nil {
+		return err
+	}
+
+	if _, err := opt.ScopeOptions(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func run(opt *options.ControllerOptions) error {
+	defer klog.Flush()
+	log.SetLogger(klog.NewKlogr())
+
+	cfg, err := utils.BuildRestConfig(opt.KubeConfig)
+	if err != nil {
+		return err
+	}
+
+	simulatorOptions, err := opt.SimulatorOptions(nil)
+	if err != nil {
+		return err
+	}
+
+	loader, err := opt.Loader()
+	if err != nil {
+		return err
+	}
+
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		return err
+	}
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		return err
+	}
+
+	mgr, err := manager.New(cfg, manager.Options{
+		Scheme:                 scheme,
+		MetricsBindAddress:     opt.MetricsBindAddress,
+		HealthProbeBindAddress: opt.HealthProbeBindAddress,
+		LeaderElection:         opt.LeaderElect,
+		LeaderElectionID:       "kluster-capacity-controller",
+	})
+	if err != nil {
+		return err
+	}
+
+	controllerOptions := &pkgcontroller.Options{
+		Loader:           loader,
+		SimulatorOptions: simulatorOptions,
+		Timeout:          opt.Timeout,
+	}
+	if err := (&pkgcontroller.CapacityEstimationReconciler{Client: mgr.GetClient(), Options: controllerOptions}).SetupWithManager(mgr); err != nil {
+		return err
+	}
+	if err := (&pkgcontroller.ClusterCompressionReconciler{Client: mgr.GetClient(), Options: controllerOptions}).SetupWithManager(mgr); err != nil {
+		return err
+	}
+
+	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
+		return err
+	}
+	if err := mgr.AddReadyzCheck("readyz", healthz.Ping); err != nil {
+		return err
+	}
+
+	// the timeout is applied to each simulation instead of the whole controller
+	return mgr.Start(signals.SetupSignalHandler())
+}
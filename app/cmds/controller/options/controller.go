@@ -0,0 +1,40 @@
+package options
+
+import (
+	"os"
+
+	"github.com/spf13/pflag"
+
+	"github.com/k-cloud-labs/kluster-capacity/app/cmds"
+)
+
+type ControllerOptions struct {
+	cmds.Options
+	MetricsBindAddress     string
+	HealthProbeBindAddress string
+	LeaderElect            bool
+}
+
+func NewControllerOptions() *ControllerOptions {
+	return &ControllerOptions{}
+}
+
+// Default uses KUBECONFIG if kubeconfig is not specified, the in cluster config is used if neither is set,
+// since the controller usually runs in the cluster.
+func (s *ControllerOptions) Default() {
+	if len(s.KubeConfig) == 0 {
+		s.KubeConfig = os.Getenv("KUBECONFIG")
+	}
+}
+
+func (s *ControllerOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&s.KubeConfig, "kubeconfig", s.KubeConfig, "Path to the kubeconfig file of the cluster to watch the reviews and copy the world from. By default the in cluster config")
+	fs.StringVar(&s.SchedulerConfig, "schedulerconfig", s.SchedulerConfig, "Path to JSON or YAML file containing scheduler configuration")
+	fs.StringVar(&s.Snapshot, "snapshot", s.Snapshot, "Path of snapshot to initialize the world instead of copying from the cluster, it's read again before each simulation")
+	s.AddCommonFlags(fs)
+	fs.StringSliceVar(&s.ExcludeNodes, "exclude-nodes", s.ExcludeNodes, "Exclude nodes to be scheduled in all simulations, more nodes could be excluded by each review")
+	fs.IntVar(&s.MaxLimit, "max-limit", 0, "Default max number of replicas of ce and nodes of cc, overridden by maxLimit of each review. By default unlimited")
+	fs.StringVar(&s.MetricsBindAddress, "metrics-bind-address", ":8080", "Address the metrics endpoint binds to, 0 disables it")
+	fs.StringVar(&s.HealthProbeBindAddress, "health-probe-bind-address", ":8081", "Address the health probe endpoint binds to")
+	fs.BoolVar(&s.LeaderElect, "leader-elect", s.LeaderElect, "Enable leader election so that only one of the replicas reconciles the reviews")
+}
@@ -0,0 +1,130 @@
+/*
+Copyright © 2023 k-cloud-labs org
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exporter
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/lithammer/dedent"
+	"github.com/spf13/cobra"
+	cliflag "k8s.io/component-base/cli/flag"
+	"k8s.io/klog/v2"
+
+	"github.com/k-cloud-labs/kluster-capacity/app/cmds/exporter/options"
+	"github.com/k-cloud-labs/kluster-capacity/pkg/exporter"
+)
+
+var exporterLong = dedent.Dedent(`
+		exporter runs ce for the pod templates specified by --pods-from-template flag and cc periodically,
+		and exposes the results as prometheus metrics on /metrics, so that alerts could be fired before the
+		capacity runs out. The world is copied from the Kubernetes environment with its configuration specified
+		in KUBECONFIG, or loaded from the snapshot specified by --snapshot flag, again on each round.
+
+		kluster_capacity_schedulable_replicas{template}             replicas of the template could be scheduled
+		kluster_capacity_schedulable_replicas_on_node{template,node} replicas of the template on each node
+		kluster_capacity_removable_nodes                           nodes could be scaled down by cc
+		kluster_capacity_last_success_timestamp_seconds{simulation} unix time of the last successful ce or cc
+		kluster_capacity_failures_total{simulation}                 number of failed ce or cc
+	`)
+
+func NewExporterCmd() *cobra.Command {
+	opt := options.NewExporterOptions()
+
+	var cmd = &cobra.Command{
+		Use:           "exporter",
+		Short:         "exporter runs ce and cc periodically and exposes the results as prometheus metrics",
+		Long:          exporterLong,
+		SilenceErrors: false,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			flag.Parse()
+
+			opt.Default()
+			err := validateOptions(opt)
+			if err != nil {
+				return err
+			}
+
+			err = run(opt)
+			if err != nil {
+				return err
+			}
+
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.SetNormalizeFunc(cliflag.WordSepNormalizeFunc)
+	flags.AddGoFlagSet(flag.CommandLine)
+	opt.AddFlags(flags)
+
+	return cmd
+}
+
+func validateOptions(opt *options.ExporterOptions) error {
+	if err := opt.ValidateSource(); err != nil {
+		return err
+	}
+
+	if len(opt.PodsFromTemplate) == 0 {
+		return errors.New("pod templates are missing")
+	}
+
+	if opt.Interval <= 0 {
+		return errors.New("interval must be positive")
+	}
+
+	if err := opt.ValidateFramework(); err != nil {
+		return err
+	}
+
+	if _, err := opt.ScopeOptions(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func run(opt *options.ExporterOptions) error {
+	defer klog.Flush()
+
+	templates, err := opt.Templates()
+	if err != nil {
+		return err
+	}
+
+	simulatorOptions, err := opt.SimulatorOptions(nil)
+	if err != nil {
+		return err
+	}
+
+	loader, err := opt.Loader()
+	if err != nil {
+		return err
+	}
+
+	// the timeout is applied to each simulation instead of the whole exporter
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return exporter.New(loader, simulatorOptions, templates, opt.ClusterCompression, opt.Timeout).Run(ctx, opt.Address, opt.Interval)
+}
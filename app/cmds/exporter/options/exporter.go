@@ -0,0 +1,63 @@
+package options
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/pflag"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/k-cloud-labs/kluster-capacity/app/cmds"
+	"github.com/k-cloud-labs/kluster-capacity/pkg/utils"
+)
+
+type ExporterOptions struct {
+	cmds.Options
+	PodsFromTemplate []string
+	// run cc on each round besides ce
+	ClusterCompression bool
+	// address to serve the metrics on
+	Address string
+	// interval to reload the world and run the simulations
+	Interval time.Duration
+}
+
+func NewExporterOptions() *ExporterOptions {
+	return &ExporterOptions{}
+}
+
+func (s *ExporterOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&s.KubeConfig, "kubeconfig", s.KubeConfig, "Path to the kubeconfig file of the cluster to copy the world from")
+	fs.StringVar(&s.SchedulerConfig, "schedulerconfig", s.SchedulerConfig, "Path to JSON or YAML file containing scheduler configuration")
+	fs.StringVar(&s.Snapshot, "snapshot", s.Snapshot, "Path of snapshot to initialize the world instead of copying from the cluster, it's read again on each round")
+	s.AddCommonFlags(fs)
+	fs.StringSliceVar(&s.PodsFromTemplate, "pods-from-template", s.PodsFromTemplate, "Path or URL to JSON or YAML file containing pod definition, the name of the pod is used as the template label so it must be unique. Comma seperated")
+	fs.IntVar(&s.MaxLimit, "max-limit", 0, "Number of instances of each pod to be scheduled after which ce stops, not applied to cc. By default unlimited")
+	fs.StringSliceVar(&s.ExcludeNodes, "exclude-nodes", s.ExcludeNodes, "Exclude nodes to be scheduled")
+	fs.BoolVar(&s.ClusterCompression, "cluster-compression", true, "Run cc on each round and export the number of removable nodes")
+	fs.StringVar(&s.Address, "address", ":8080", "Address to serve the metrics on")
+	fs.DurationVar(&s.Interval, "interval", 10*time.Minute, "Interval to reload the world and run the simulations")
+}
+
+// Templates loads the pod templates, the names must be unique since they are used as the label of the metrics.
+func (s *ExporterOptions) Templates() ([]*corev1.Pod, error) {
+	names := sets.NewString()
+	pods := make([]*corev1.Pod, 0, len(s.PodsFromTemplate))
+	for _, template := range s.PodsFromTemplate {
+		pod, err := utils.LoadPodTemplate(template)
+		if err != nil {
+			return nil, err
+		}
+		if len(pod.Name) == 0 {
+			return nil, fmt.Errorf("pod in %s has no name", template)
+		}
+		if names.Has(pod.Name) {
+			return nil, fmt.Errorf("pod name %s in %s is duplicated", pod.Name, template)
+		}
+		names.Insert(pod.Name)
+		pods = append(pods, pod)
+	}
+
+	return pods, nil
+}
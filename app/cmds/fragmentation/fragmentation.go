@@ -0,0 +1,134 @@
+/*
+Copyright © 2023 k-cloud-labs org
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fragmentation
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/lithammer/dedent"
+	"github.com/spf13/cobra"
+	cliflag "k8s.io/component-base/cli/flag"
+	"k8s.io/klog/v2"
+
+	"github.com/k-cloud-labs/kluster-capacity/app/cmds/fragmentation/options"
+	"github.com/k-cloud-labs/kluster-capacity/pkg"
+	"github.com/k-cloud-labs/kluster-capacity/pkg/simulator/fragmentation"
+	"github.com/k-cloud-labs/kluster-capacity/pkg/snapshot"
+)
+
+var fragmentationLong = dedent.Dedent(`
+		fa analyzes how much free capacity of the cluster could not be used by the reference pod shapes specified
+		by --pods-from-template flag. The world is copied from the Kubernetes environment with its configuration
+		specified in KUBECONFIG, or loaded from the snapshot specified by --snapshot flag. For each pod shape, the
+		pods are scheduled by ce until the cluster runs out of resources, then the free capacity of each node, i.e.
+		allocatable minus the requests of the pods bound to it, is compared with the requests of the replicas placed
+		on it. The rest is stranded, and the fragmentation ratio of each resource is stranded divided by free.
+	`)
+
+func NewFragmentationCmd() *cobra.Command {
+	opt := options.NewFragmentationOptions()
+
+	var cmd = &cobra.Command{
+		Use:           "fa --kubeconfig KUBECONFIG --pods-from-template PODYAML",
+		Short:         "fa is used to analyze the fragmentation of free capacity for specified pod shapes",
+		Long:          fragmentationLong,
+		SilenceErrors: false,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			flag.Parse()
+
+			opt.Default()
+			err := validate(opt)
+			if err != nil {
+				return err
+			}
+
+			err = run(opt)
+			if err != nil {
+				return err
+			}
+
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.SetNormalizeFunc(cliflag.WordSepNormalizeFunc)
+	flags.AddGoFlagSet(flag.CommandLine)
+	opt.AddFlags(flags)
+
+	return cmd
+}
+
+func validate(opt *options.FragmentationOptions) error {
+	if len(opt.PodsFromTemplate) == 0 {
+		return errors.New("pod template file is missing")
+	}
+
+	if err := opt.ValidateSource(); err != nil {
+		return err
+	}
+
+	if _, err := opt.ScopeOptions(); err != nil {
+		return err
+	}
+
+	return opt.ValidateFramework()
+}
+
+func run(opt *options.FragmentationOptions) error {
+	defer klog.Flush()
+	conf := options.NewFragmentationConfig(opt)
+
+	if len(opt.Snapshot) > 0 {
+		objs, err := snapshot.LoadObjects(opt.Snapshot)
+		if err != nil {
+			return err
+		}
+		conf.InitObjs = objs
+	}
+
+	err := conf.ParseAPISpec()
+	if err != nil {
+		return fmt.Errorf("failed to parse pod spec file: %v ", err)
+	}
+
+	ctx, cancel := opt.Context()
+	defer cancel()
+
+	report, err := runSimulator(ctx, conf)
+	if err != nil {
+		return err
+	}
+
+	if err := report.Print(conf.Options.Verbose, conf.Options.OutputFormat); err != nil {
+		return fmt.Errorf("error while printing: %v", err)
+	}
+
+	return nil
+}
+
+func runSimulator(ctx context.Context, conf *options.FragmentationConfig) (pkg.Printer, error) {
+	simulatorConfig, err := conf.SimulatorConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	return fragmentation.Analyze(ctx, simulatorConfig)
+}
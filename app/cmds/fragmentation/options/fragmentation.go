@@ -0,0 +1,77 @@
+package options
+
+import (
+	"github.com/spf13/pflag"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/k-cloud-labs/kluster-capacity/app/cmds"
+	"github.com/k-cloud-labs/kluster-capacity/pkg/simulator/fragmentation"
+	"github.com/k-cloud-labs/kluster-capacity/pkg/utils"
+)
+
+type FragmentationOptions struct {
+	cmds.Options
+	PodsFromTemplate []string
+	// max number of pod templates simulated at the same time, unlimited if not positive
+	Parallelism int
+	// max number of nodes reported for each pod template, all if not positive
+	TopNodes int
+}
+
+type FragmentationConfig struct {
+	Pods     []*corev1.Pod
+	InitObjs []runtime.Object
+	Options  *FragmentationOptions
+}
+
+func NewFragmentationConfig(opt *FragmentationOptions) *FragmentationConfig {
+	return &FragmentationConfig{
+		Options: opt,
+	}
+}
+
+func NewFragmentationOptions() *FragmentationOptions {
+	return &FragmentationOptions{}
+}
+
+func (s *FragmentationOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&s.KubeConfig, "kubeconfig", s.KubeConfig, "Path to the kubeconfig file to use for the analysis")
+	fs.StringSliceVar(&s.PodsFromTemplate, "pods-from-template", s.PodsFromTemplate, "Path or URL to JSON or YAML file containing the reference pod shape. Comma seperated")
+	fs.StringVar(&s.SchedulerConfig, "schedulerconfig", s.SchedulerConfig, "Path to JSON or YAML file containing scheduler configuration")
+	fs.BoolVar(&s.Verbose, "verbose", s.Verbose, "Verbose mode")
+	fs.StringVarP(&s.OutputFormat, "output", "o", s.OutputFormat, "Output format. One of: json|yaml (Note: output is not versioned or guaranteed to be stable across releases)")
+	fs.StringSliceVar(&s.ExcludeNodes, "exclude-nodes", s.ExcludeNodes, "Exclude nodes to be scheduled, their free capacity is not counted either")
+	fs.StringVar(&s.Snapshot, "snapshot", s.Snapshot, "Path of snapshot to initialize the world instead of copying from the cluster")
+	s.AddCommonFlags(fs)
+	fs.IntVar(&s.Parallelism, "parallelism", s.Parallelism, "Max number of pod templates simulated at the same time. By default unlimited")
+	fs.IntVar(&s.TopNodes, "top", 10, "Max number of nodes contributing most stranded capacity reported for each pod template, 0 means all")
+}
+
+// ParseAPISpec loads the pod templates.
+func (s *FragmentationConfig) ParseAPISpec() error {
+	for _, template := range s.Options.PodsFromTemplate {
+		pod, err := utils.LoadPodTemplate(template)
+		if err != nil {
+			return err
+		}
+		s.Pods = append(s.Pods, pod)
+	}
+
+	return nil
+}
+
+// SimulatorConfig converts the config to the config of the fa simulator, pods must be parsed before.
+func (s *FragmentationConfig) SimulatorConfig() (*fragmentation.Config, error) {
+	options, err := s.Options.SimulatorOptions(s.InitObjs)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fragmentation.Config{
+		Options:     options,
+		Pods:        s.Pods,
+		Parallelism: s.Options.Parallelism,
+		TopNodes:    s.Options.TopNodes,
+	}, nil
+}
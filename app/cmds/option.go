@@ -1,10 +1,30 @@
 package cmds
 
 import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/k-cloud-labs/kluster-capacity/pkg"
+	"github.com/k-cloud-labs/kluster-capacity/pkg/framework"
+	"github.com/k-cloud-labs/kluster-capacity/pkg/plugins/volcano"
+	"github.com/k-cloud-labs/kluster-capacity/pkg/simulator"
+	"github.com/k-cloud-labs/kluster-capacity/pkg/utils"
 )
 
+// ErrNoSource is returned if the world could neither be copied from a cluster nor loaded from a snapshot.
+var ErrNoSource = errors.New("either kubeconfig or snapshot must be specified")
+
 type Options struct {
 	SchedulerConfig string
 	KubeConfig      string
@@ -16,10 +36,29 @@ type Options struct {
 	SaveTo       string
 	ExcludeNodes []string
 	MaxLimit     int
+	// scheduler framework used to simulate, one of kube-scheduler|volcano
+	Framework string
+	// max duration of the simulation, no limit if zero
+	Timeout time.Duration
+	// skip optional resources which are forbidden to list instead of failing
+	Tolerant bool
+	// scope of the simulated world, only matching nodes and pods are loaded
+	NodeSelector string
+	Namespace    string
+	PodSelector  string
+	// file to write the scheduling decision of each pod as NDJSON
+	Trace string
+	// makes the simulation deterministic if not zero
+	Seed int64
+	// max time a pod waits for the other members of its gang with volcano framework
+	GangWaitTime time.Duration
+
+	tracer *framework.Tracer
 }
 
 func (o *Options) Default() {
-	if len(o.KubeConfig) == 0 {
+	// no cluster is needed when the world is initialized from snapshot
+	if len(o.KubeConfig) == 0 && len(o.Snapshot) == 0 {
 		config := os.Getenv("KUBECONFIG")
 		if len(config) == 0 {
 			config = filepath.Join(os.Getenv("HOME"), ".kube/config")
@@ -27,3 +66,186 @@ func (o *Options) Default() {
 		o.KubeConfig = config
 	}
 }
+
+// AddCommonFlags adds the flags shared by the commands running simulations.
+func (o *Options) AddCommonFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.Framework, "framework", pkg.FrameworkKubeScheduler, "Scheduler framework used to simulate. One of: kube-scheduler|volcano")
+	fs.DurationVar(&o.Timeout, "timeout", o.Timeout, "Max duration of each simulation, a partial result is reported when it is reached. By default unlimited")
+	fs.DurationVar(&o.GangWaitTime, "gang-wait-time", volcano.DefaultGangWaitTime, "Max time a pod waits for the other members of its PodGroup with volcano framework, gang members not scheduled in time are reported as unschedulable. Raise it for large or slow simulations")
+	fs.Int64Var(&o.Seed, "seed", o.Seed, "Seed to make the simulations deterministic so that identical inputs against the same world give identical results. By default 0, which means ties are broken randomly")
+	o.AddScopeFlags(fs)
+}
+
+// AddScopeFlags adds the flags to load the world, which are shared by all commands copying the world from a cluster.
+func (o *Options) AddScopeFlags(fs *pflag.FlagSet) {
+	fs.BoolVar(&o.Tolerant, "tolerant", o.Tolerant, "Skip optional resources (CSIStorageCapacity, ResourceClaim, PodDisruptionBudget) which are forbidden to list instead of failing, they are reported as warnings")
+	fs.StringVar(&o.NodeSelector, "node-selector", o.NodeSelector, "Label selector of nodes to load, only matching nodes and pods bound to them are simulated")
+	fs.StringVar(&o.Namespace, "namespace", o.Namespace, "Namespace of pending pods to load, pods bound to the loaded nodes are always loaded")
+	fs.StringVar(&o.PodSelector, "pod-selector", o.PodSelector, "Label selector of pending pods to load, pods bound to the loaded nodes are always loaded")
+}
+
+// ValidateSource checks whether the world could be copied from a cluster or loaded from a snapshot. since Default
+// always fills the kubeconfig if no snapshot is given, the kubeconfig must exist as well.
+func (o *Options) ValidateSource() error {
+	if len(o.Snapshot) > 0 {
+		return nil
+	}
+
+	if len(o.KubeConfig) == 0 {
+		return ErrNoSource
+	}
+
+	if _, err := os.Stat(o.KubeConfig); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%w, kubeconfig %s does not exist", ErrNoSource, o.KubeConfig)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// ValidateFramework checks whether the framework is supported.
+func (o *Options) ValidateFramework() error {
+	switch o.Framework {
+	case pkg.FrameworkKubeScheduler, pkg.FrameworkVolcano:
+		return nil
+	default:
+		return fmt.Errorf("unsupported framework %s, one of: %s|%s", o.Framework, pkg.FrameworkKubeScheduler, pkg.FrameworkVolcano)
+	}
+}
+
+// Context returns the context of the simulation, it's canceled when the process is interrupted
+// or the timeout is reached.
+func (o *Options) Context() (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	if o.Timeout <= 0 {
+		return ctx, stop
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, o.Timeout)
+	return ctx, func() {
+		cancel()
+		stop()
+	}
+}
+
+// ScopeOptions returns the framework options to scope the simulated world by node selector, namespace and pod selector.
+func (o *Options) ScopeOptions() ([]framework.Option, error) {
+	nodeSelector, podSelector, err := o.selectors()
+	if err != nil {
+		return nil, err
+	}
+
+	var options []framework.Option
+	if nodeSelector != nil {
+		options = append(options, framework.WithNodeSelector(nodeSelector))
+	}
+
+	if len(o.Namespace) > 0 {
+		options = append(options, framework.WithNamespace(o.Namespace))
+	}
+
+	if podSelector != nil {
+		options = append(options, framework.WithPodSelector(podSelector))
+	}
+
+	return options, nil
+}
+
+// SimulatorOptions returns the options shared by the simulators, the world is initialized from initObjs if not empty.
+// the tracer is only set if OpenTrace is called before.
+func (o *Options) SimulatorOptions(initObjs []runtime.Object) (simulator.Options, error) {
+	nodeSelector, podSelector, err := o.selectors()
+	if err != nil {
+		return simulator.Options{}, err
+	}
+
+	source := simulator.SourceCluster
+	if len(o.Snapshot) > 0 {
+		source = simulator.SourceObjects
+	}
+
+	return simulator.Options{
+		Source:          source,
+		InitObjs:        initObjs,
+		KubeConfig:      o.KubeConfig,
+		SchedulerConfig: o.SchedulerConfig,
+		Framework:       o.Framework,
+		ExcludeNodes:    o.ExcludeNodes,
+		MaxLimit:        o.MaxLimit,
+		Tolerant:        o.Tolerant,
+		NodeSelector:    nodeSelector,
+		Namespace:       o.Namespace,
+		PodSelector:     podSelector,
+		Tracer:          o.tracer,
+		Seed:            o.Seed,
+		GangWaitTime:    o.GangWaitTime,
+	}, nil
+}
+
+// Loader returns the loader of the world, it's loaded from the snapshot if specified, otherwise from the cluster.
+func (o *Options) Loader() (simulator.Loader, error) {
+	if len(o.Snapshot) > 0 {
+		return simulator.SnapshotLoader(o.Snapshot), nil
+	}
+
+	cfg, err := utils.BuildRestConfig(o.KubeConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	scopeOptions, err := o.ScopeOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	return simulator.ClusterLoader(cfg, append(scopeOptions, framework.WithTolerant(o.Tolerant))...), nil
+}
+
+// selectors parses the node selector and the pod selector, they are nil if not specified.
+func (o *Options) selectors() (nodeSelector labels.Selector, podSelector labels.Selector, err error) {
+	if len(o.NodeSelector) > 0 {
+		nodeSelector, err = labels.Parse(o.NodeSelector)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid node selector: %v", err)
+		}
+	}
+
+	if len(o.PodSelector) > 0 {
+		podSelector, err = labels.Parse(o.PodSelector)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid pod selector: %v", err)
+		}
+	}
+
+	return nodeSelector, podSelector, nil
+}
+
+// OpenTrace creates the trace file if specified, the returned func flushes and closes it and must be called
+// after the simulation.
+func (o *Options) OpenTrace() (func() error, error) {
+	if len(o.Trace) == 0 {
+		return func() error { return nil }, nil
+	}
+
+	f, err := os.Create(o.Trace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace file: %v", err)
+	}
+	w := bufio.NewWriter(f)
+	o.tracer = framework.NewTracer(w)
+
+	return func() error {
+		if err := w.Flush(); err != nil {
+			_ = f.Close()
+			return fmt.Errorf("failed to write trace file: %v", err)
+		}
+		return f.Close()
+	}, nil
+}
+
+// Tracer returns the tracer opened by OpenTrace, nil if no trace file is specified.
+func (o *Options) Tracer() *framework.Tracer {
+	return o.tracer
+}
@@ -5,6 +5,8 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 
 	"github.com/k-cloud-labs/kluster-capacity/app/cmds"
+	"github.com/k-cloud-labs/kluster-capacity/pkg/simulator"
+	"github.com/k-cloud-labs/kluster-capacity/pkg/simulator/schedulersimulation"
 )
 
 const (
@@ -19,11 +21,6 @@ const (
 	ExitWhenAllSucceed = "AllSucceed"
 )
 
-type Snapshot struct {
-	// key is gk
-	Objects map[string][]runtime.Object `json:"objects"`
-}
-
 type SchedulerSimulationOptions struct {
 	cmds.Options
 	// Cluster, Snapshot
@@ -47,6 +44,25 @@ func NewSchedulerSimulationConfig(option *SchedulerSimulationOptions) *Scheduler
 	}
 }
 
+// SimulatorConfig converts the config to the config of the ss simulator.
+func (s *SchedulerSimulationConfig) SimulatorConfig() (*schedulersimulation.Config, error) {
+	options, err := s.Options.SimulatorOptions(s.InitObjs)
+	if err != nil {
+		return nil, err
+	}
+	// --snapshot is ignored unless the source is snapshot
+	options.Source = simulator.SourceCluster
+	if s.Options.SourceFrom == FromSnapshot {
+		options.Source = simulator.SourceObjects
+	}
+
+	return &schedulersimulation.Config{
+		Options:       options,
+		ExitCondition: s.Options.ExitCondition,
+		SaveTo:        s.Options.SaveTo,
+	}, nil
+}
+
 func (s *SchedulerSimulationOptions) AddFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&s.KubeConfig, "kubeconfig", s.KubeConfig, "Path to the kubeconfig file to use for the analysis")
 	fs.StringVar(&s.SchedulerConfig, "schedulerconfig", s.SchedulerConfig, "Path to JSON or YAML file containing scheduler configuration. Used when source-from is cluster")
@@ -54,6 +70,8 @@ func (s *SchedulerSimulationOptions) AddFlags(fs *pflag.FlagSet) {
 	fs.StringSliceVar(&s.ExcludeNodes, "exclude-nodes", s.ExcludeNodes, "Exclude nodes to be scheduled")
 	fs.BoolVarP(&s.IgnorePodsOnExcludeNodes, "ignore-pods-on-excludes-nodes", "i", true, "Whether ignore the pods on the excludes nodes. By default true")
 	fs.StringVar(&s.Snapshot, "snapshot", s.Snapshot, "Path of snapshot to initialize the world. Used when source-from is snapshot")
+	s.AddCommonFlags(fs)
+	fs.StringVar(&s.Trace, "trace", s.Trace, "File to write the filter and score results of each scheduled pod as NDJSON")
 	fs.StringVar(&s.SourceFrom, "source-from", "Cluster", "Source of the init data. One of: Cluster|Snapshot")
 	fs.StringVar(&s.ExitCondition, "exit-condition", "AllSucceed", "Exit condition of the simulator. One of: AllScheduled|AllSucceed")
 	fs.BoolVar(&s.Verbose, "verbose", s.Verbose, "Verbose mode")
@@ -17,6 +17,7 @@ limitations under the License.
 package schedulersimulation
 
 import (
+	"context"
 	"errors"
 	"flag"
 	"fmt"
@@ -29,11 +30,13 @@ import (
 	"github.com/k-cloud-labs/kluster-capacity/app/cmds/schedulersimulation/options"
 	"github.com/k-cloud-labs/kluster-capacity/pkg"
 	"github.com/k-cloud-labs/kluster-capacity/pkg/simulator/schedulersimulation"
+	"github.com/k-cloud-labs/kluster-capacity/pkg/snapshot"
 )
 
 var schedulerSimulationLong = dedent.Dedent(`
 		ss simulates an API server with initial state copied from the Kubernetes environment
-		with its configuration specified in KUBECONFIG. The simulated API server tries to schedule the number of
+		with its configuration specified in KUBECONFIG, or loaded from the snapshot specified by --snapshot flag
+		when --source-from is Snapshot. The simulated API server tries to schedule the number of
 		pods from existing cluster.
 	`)
 
@@ -81,26 +84,47 @@ func validate(opt *options.SchedulerSimulationOptions) error {
 		return errors.New("snapshot must be specified when source-from is snapshot")
 	}
 
+	if opt.SourceFrom != options.FromCluster && opt.SourceFrom != options.FromSnapshot {
+		return errors.New("source from must be Cluster or Snapshot")
+	}
+
 	if opt.ExitCondition != options.ExitWhenAllSucceed && opt.ExitCondition != options.ExitWhenAllScheduled {
 		return errors.New("exit condition must be AllSucceed or AllScheduled")
 	}
 
-	if len(opt.KubeConfig) == 0 {
-		return errors.New("kubeconfig is missing")
+	if _, err := opt.ScopeOptions(); err != nil {
+		return err
 	}
 
-	return nil
+	return opt.ValidateFramework()
 }
 
 func run(opt *options.SchedulerSimulationOptions) error {
 	defer klog.Flush()
 	conf := options.NewSchedulerSimulationConfig(opt)
 
-	// TODO: init simulator from snapshot
-	//if opt.SourceFrom == options.FromSnapshot {
-	//}
+	if opt.SourceFrom == options.FromSnapshot {
+		objs, err := snapshot.LoadObjects(opt.Snapshot)
+		if err != nil {
+			return err
+		}
+		conf.InitObjs = objs
+	}
+
+	closeTrace, err := opt.OpenTrace()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := closeTrace(); err != nil {
+			klog.ErrorS(err, "Failed to close trace file")
+		}
+	}()
+
+	ctx, cancel := opt.Context()
+	defer cancel()
 
-	reports, err := runSimulator(conf)
+	reports, err := runSimulator(ctx, conf)
 	if err != nil {
 		return err
 	}
@@ -112,21 +136,11 @@ func run(opt *options.SchedulerSimulationOptions) error {
 	return nil
 }
 
-func runSimulator(conf *options.SchedulerSimulationConfig) (pkg.Printer, error) {
-	s, err := schedulersimulation.NewSSSimulatorExecutor(conf)
-	if err != nil {
-		return nil, err
-	}
-
-	err = s.Initialize(conf.InitObjs...)
-	if err != nil {
-		return nil, err
-	}
-
-	err = s.Run()
+func runSimulator(ctx context.Context, conf *options.SchedulerSimulationConfig) (pkg.Printer, error) {
+	simulatorConfig, err := conf.SimulatorConfig()
 	if err != nil {
 		return nil, err
 	}
 
-	return s.Report(), nil
+	return schedulersimulation.Simulate(ctx, simulatorConfig)
 }
@@ -0,0 +1,32 @@
+package options
+
+import (
+	"time"
+
+	"github.com/spf13/pflag"
+
+	"github.com/k-cloud-labs/kluster-capacity/app/cmds"
+)
+
+type ServeOptions struct {
+	cmds.Options
+	// address to listen on
+	Address string
+	// interval to refresh the world, never refreshed if zero
+	RefreshInterval time.Duration
+}
+
+func NewServeOptions() *ServeOptions {
+	return &ServeOptions{}
+}
+
+func (s *ServeOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&s.KubeConfig, "kubeconfig", s.KubeConfig, "Path to the kubeconfig file of the cluster to copy the world from")
+	fs.StringVar(&s.SchedulerConfig, "schedulerconfig", s.SchedulerConfig, "Path to JSON or YAML file containing scheduler configuration")
+	fs.StringVar(&s.Snapshot, "snapshot", s.Snapshot, "Path of snapshot to initialize the world instead of copying from the cluster, it's read again on each refresh")
+	s.AddCommonFlags(fs)
+	fs.StringSliceVar(&s.ExcludeNodes, "exclude-nodes", s.ExcludeNodes, "Exclude nodes to be scheduled in all simulations, more nodes could be excluded by each request")
+	fs.IntVar(&s.MaxLimit, "max-limit", 0, "Default max number of replicas of ce and nodes of cc, overridden by maxLimit of each request. By default unlimited")
+	fs.StringVar(&s.Address, "address", ":8080", "Address to serve the REST API on")
+	fs.DurationVar(&s.RefreshInterval, "refresh-interval", 5*time.Minute, "Interval to reload the world from the cluster or the snapshot, 0 means never")
+}
@@ -0,0 +1,119 @@
+/*
+Copyright © 2023 k-cloud-labs org
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package serve
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/lithammer/dedent"
+	"github.com/spf13/cobra"
+	cliflag "k8s.io/component-base/cli/flag"
+	"k8s.io/klog/v2"
+
+	"github.com/k-cloud-labs/kluster-capacity/app/cmds/serve/options"
+	"github.com/k-cloud-labs/kluster-capacity/pkg/server"
+)
+
+var serveLong = dedent.Dedent(`
+		serve exposes ce, cc and ss as a REST API. The world is copied from the Kubernetes environment
+		with its configuration specified in KUBECONFIG, or loaded from the snapshot specified by --snapshot flag,
+		it's cached between requests and refreshed on the interval specified by --refresh-interval flag.
+
+		POST /api/v1/capacityestimation   {"pods": [<pod template>], "maxLimit": 10}
+		POST /api/v1/clustercompression   {"maxLimit": 10, "filterNodeOptions": {"ignoreStaticPod": true}}
+		POST /api/v1/schedulersimulation  {"exitCondition": "AllScheduled"}
+		GET  /healthz
+	`)
+
+func NewServeCmd() *cobra.Command {
+	opt := options.NewServeOptions()
+
+	var cmd = &cobra.Command{
+		Use:           "serve",
+		Short:         "serve exposes ce, cc and ss over HTTP against a cached world",
+		Long:          serveLong,
+		SilenceErrors: false,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			flag.Parse()
+
+			opt.Default()
+			err := validateOptions(opt)
+			if err != nil {
+				return err
+			}
+
+			err = run(opt)
+			if err != nil {
+				return err
+			}
+
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.SetNormalizeFunc(cliflag.WordSepNormalizeFunc)
+	flags.AddGoFlagSet(flag.CommandLine)
+	opt.AddFlags(flags)
+
+	return cmd
+}
+
+func validateOptions(opt *options.ServeOptions) error {
+	if err := opt.ValidateSource(); err != nil {
+		return err
+	}
+
+	if opt.RefreshInterval < 0 {
+		return errors.New("refresh interval must not be negative")
+	}
+
+	if err := opt.ValidateFramework(); err != nil {
+		return err
+	}
+
+	if _, err := opt.ScopeOptions(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func run(opt *options.ServeOptions) error {
+	defer klog.Flush()
+
+	simulatorOptions, err := opt.SimulatorOptions(nil)
+	if err != nil {
+		return err
+	}
+
+	loader, err := opt.Loader()
+	if err != nil {
+		return err
+	}
+
+	// the timeout is applied to each request instead of the whole server
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return server.New(loader, simulatorOptions, opt.Timeout).Run(ctx, opt.Address, opt.RefreshInterval)
+}
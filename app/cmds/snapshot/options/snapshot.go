@@ -0,0 +1,21 @@
+package options
+
+import (
+	"github.com/spf13/pflag"
+
+	"github.com/k-cloud-labs/kluster-capacity/app/cmds"
+)
+
+type SnapshotOptions struct {
+	cmds.Options
+}
+
+func NewSnapshotOptions() *SnapshotOptions {
+	return &SnapshotOptions{}
+}
+
+func (s *SnapshotOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&s.KubeConfig, "kubeconfig", s.KubeConfig, "Path to the kubeconfig file of the cluster to capture")
+	fs.StringVarP(&s.SaveTo, "output", "o", s.SaveTo, "File path to save the snapshot")
+	s.AddScopeFlags(fs)
+}
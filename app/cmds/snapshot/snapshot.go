@@ -0,0 +1,121 @@
+/*
+Copyright © 2023 k-cloud-labs org
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package snapshot
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/lithammer/dedent"
+	"github.com/spf13/cobra"
+	cliflag "k8s.io/component-base/cli/flag"
+	"k8s.io/klog/v2"
+
+	"github.com/k-cloud-labs/kluster-capacity/app/cmds/snapshot/options"
+	"github.com/k-cloud-labs/kluster-capacity/pkg/framework"
+	"github.com/k-cloud-labs/kluster-capacity/pkg/snapshot"
+	"github.com/k-cloud-labs/kluster-capacity/pkg/utils"
+)
+
+var snapshotLong = dedent.Dedent(`
+		snapshot captures all the resources used by the simulators from the Kubernetes environment
+		with its configuration specified in KUBECONFIG, and saves them to the file specified by --output flag.
+		The snapshot could be used by ce, cc and ss later to reproduce the analysis against exactly the same state.
+	`)
+
+func NewSnapshotCmd() *cobra.Command {
+	opt := options.NewSnapshotOptions()
+
+	var cmd = &cobra.Command{
+		Use:           "snapshot --kubeconfig KUBECONFIG --output FILE",
+		Short:         "snapshot is used to capture the cluster world to a file",
+		Long:          snapshotLong,
+		SilenceErrors: false,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			flag.Parse()
+
+			opt.Default()
+			err := validate(opt)
+			if err != nil {
+				return err
+			}
+
+			err = run(opt)
+			if err != nil {
+				return err
+			}
+
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.SetNormalizeFunc(cliflag.WordSepNormalizeFunc)
+	flags.AddGoFlagSet(flag.CommandLine)
+	opt.AddFlags(flags)
+
+	return cmd
+}
+
+func validate(opt *options.SnapshotOptions) error {
+	if len(opt.KubeConfig) == 0 {
+		return errors.New("kubeconfig is missing")
+	}
+
+	if len(opt.SaveTo) == 0 {
+		return errors.New("output file is missing")
+	}
+
+	_, err := opt.ScopeOptions()
+	return err
+}
+
+func run(opt *options.SnapshotOptions) error {
+	defer klog.Flush()
+
+	cfg, err := utils.BuildRestConfig(opt.KubeConfig)
+	if err != nil {
+		return err
+	}
+
+	scopeOptions, err := opt.ScopeOptions()
+	if err != nil {
+		return err
+	}
+
+	objs, warnings, err := framework.GetInitObjects(cfg, append(scopeOptions, framework.WithTolerant(opt.Tolerant))...)
+	if err != nil {
+		return err
+	}
+	for _, warning := range warnings {
+		klog.Warning(warning)
+	}
+
+	s, err := snapshot.New(objs)
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot: %v", err)
+	}
+
+	if err := s.Save(opt.SaveTo); err != nil {
+		return fmt.Errorf("failed to save snapshot: %v", err)
+	}
+
+	klog.V(2).InfoS("Snapshot saved", "file", opt.SaveTo, "objects", len(objs))
+
+	return nil
+}
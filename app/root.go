@@ -26,7 +26,12 @@ import (
 
 	"github.com/k-cloud-labs/kluster-capacity/app/cmds/capacityestimation"
 	"github.com/k-cloud-labs/kluster-capacity/app/cmds/clustercompression"
+	"github.com/k-cloud-labs/kluster-capacity/app/cmds/controller"
+	"github.com/k-cloud-labs/kluster-capacity/app/cmds/exporter"
+	"github.com/k-cloud-labs/kluster-capacity/app/cmds/fragmentation"
 	"github.com/k-cloud-labs/kluster-capacity/app/cmds/schedulersimulation"
+	"github.com/k-cloud-labs/kluster-capacity/app/cmds/serve"
+	"github.com/k-cloud-labs/kluster-capacity/app/cmds/snapshot"
 	"github.com/k-cloud-labs/kluster-capacity/pkg/version/sharedcommand"
 )
 
@@ -66,7 +71,7 @@ func init() {
 	// when this action is called directly.
 	rootCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 
-	rootCmd.AddCommand(capacityestimation.NewCapacityEstimationCmd(), schedulersimulation.NewSchedulerSimulationCmd(), clustercompression.NewClusterCompressionCmd())
+	rootCmd.AddCommand(capacityestimation.NewCapacityEstimationCmd(), schedulersimulation.NewSchedulerSimulationCmd(), clustercompression.NewClusterCompressionCmd(), snapshot.NewSnapshotCmd(), serve.NewServeCmd(), controller.NewControllerCmd(), exporter.NewExporterCmd(), fragmentation.NewFragmentationCmd())
 	rootCmd.AddCommand(sharedcommand.NewCmdVersion(os.Stdout, "kluster-capacity"))
 }
 
@@ -0,0 +1,266 @@
+/*
+Copyright © 2023 k-cloud-labs org
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// benchmark runs the framework and the simulators against synthetic worlds of different scales and reports the time
+// and memory used by each suite, e.g.
+//
+//	go run ./hack/tools/benchmark --nodes 1000,5000,10000 --suites store,cc
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+
+	pkgframework "github.com/k-cloud-labs/kluster-capacity/pkg/framework"
+	"github.com/k-cloud-labs/kluster-capacity/pkg/simulator"
+	"github.com/k-cloud-labs/kluster-capacity/pkg/simulator/capacityestimation"
+	"github.com/k-cloud-labs/kluster-capacity/pkg/simulator/clustercompression"
+	"github.com/k-cloud-labs/kluster-capacity/pkg/utils"
+)
+
+var (
+	nodes       = flag.String("nodes", "1000,5000,10000", "comma separated number of nodes of the synthetic worlds")
+	podsPerNode = flag.Int("pods-per-node", 10, "number of pods bound to each node besides a daemonset pod")
+	suites      = flag.String("suites", "store,cc,ce", "comma separated suites to run, one of: store|cc|ce")
+	maxLimit    = flag.Int("max-limit", 0, "max number of nodes scaled down by cc and replicas estimated by ce, unlimited if zero")
+	timeout     = flag.Duration("timeout", 30*time.Minute, "max duration of each simulation")
+	cpuProfile  = flag.String("cpuprofile", "", "file to write the cpu profile of all suites")
+)
+
+const rowFormat = "%-6s %6s %7s %12s %10s %7s  %s\n"
+
+// suite runs against objs and returns a short summary of the result.
+type suite func(objs []kruntime.Object) (string, error)
+
+func main() {
+	klog.InitFlags(nil)
+	flag.Parse()
+
+	if len(*cpuProfile) > 0 {
+		f, err := os.Create(*cpuProfile)
+		if err != nil {
+			klog.Fatalf("failed to create cpu profile: %v", err)
+		}
+		defer f.Close()
+		if err := pprof.StartCPUProfile(f); err != nil {
+			klog.Fatalf("failed to start cpu profile: %v", err)
+		}
+		defer pprof.StopCPUProfile()
+	}
+
+	all := map[string]suite{
+		"store": runStore,
+		"cc":    runClusterCompression,
+		"ce":    runCapacityEstimation,
+	}
+
+	fmt.Printf(rowFormat, "SUITE", "NODES", "PODS", "DURATION", "ALLOCATED", "HEAP", "RESULT")
+	for _, n := range strings.Split(*nodes, ",") {
+		numNodes, err := strconv.Atoi(strings.TrimSpace(n))
+		if err != nil {
+			klog.Fatalf("invalid number of nodes %q: %v", n, err)
+		}
+		objs := newWorld(numNodes, *podsPerNode)
+
+		for _, name := range strings.Split(*suites, ",") {
+			run, ok := all[strings.TrimSpace(name)]
+			if !ok {
+				klog.Fatalf("unknown suite %q", name)
+			}
+
+			runtime.GC()
+			var before, after runtime.MemStats
+			runtime.ReadMemStats(&before)
+			start := time.Now()
+
+			result, err := run(objs)
+			if err != nil {
+				result = "error: " + err.Error()
+			}
+
+			duration := time.Since(start)
+			runtime.ReadMemStats(&after)
+			fmt.Printf(rowFormat, name, strconv.Itoa(numNodes), strconv.Itoa(numNodes*(*podsPerNode+1)), duration.Round(time.Millisecond).String(),
+				fmt.Sprintf("%dMi", (after.TotalAlloc-before.TotalAlloc)>>20), fmt.Sprintf("%dMi", after.HeapInuse>>20), result)
+		}
+	}
+}
+
+// runStore initializes the world and reads it the way cc selects nodes, all nodes are listed and pods of each node
+// are looked up.
+func runStore(objs []kruntime.Object) (string, error) {
+	cc, err := utils.BuildKubeSchedulerCompletedConfig("", "")
+	if err != nil {
+		return "", err
+	}
+	framework, err := pkgframework.NewKubeSchedulerFramework(cc, nil)
+	if err != nil {
+		return "", err
+	}
+	if err := framework.Initialize(objs...); err != nil {
+		return "", err
+	}
+
+	nodeList, err := cc.Client.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return "", err
+	}
+	pods := 0
+	for i := range nodeList.Items {
+		podList, err := framework.GetPodsByNode(nodeList.Items[i].Name)
+		if err != nil {
+			return "", err
+		}
+		pods += len(podList)
+	}
+
+	return fmt.Sprintf("%d pods on %d nodes", pods, len(nodeList.Items)), nil
+}
+
+func runClusterCompression(objs []kruntime.Object) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	review, err := clustercompression.Compress(ctx, &clustercompression.Config{
+		Options: simulator.Options{
+			Source:   simulator.SourceObjects,
+			InitObjs: objs,
+			MaxLimit: *maxLimit,
+		},
+		FilterNodeOptions: clustercompression.FilterNodeOptions{
+			ExcludeNotReadyNode: true,
+			ExcludeTaintNode:    true,
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%d nodes scaled down, %s", len(review.Status.ScaleDownNodeNames), review.Status.StopReason.StopType), nil
+}
+
+func runCapacityEstimation(objs []kruntime.Object) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	reviews, err := capacityestimation.Estimate(ctx, &capacityestimation.Config{
+		Options: simulator.Options{
+			Source:   simulator.SourceObjects,
+			InitObjs: objs,
+			MaxLimit: *maxLimit,
+		},
+		Pods: []*corev1.Pod{newPod("estimated", "", "", "500m")},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%d replicas, %s", reviews[0].Status.Replicas, reviews[0].Status.StopReason.StopType), nil
+}
+
+// newWorld returns numNodes nodes of 32 cpu, each of them has a daemonset pod and podsPerNode pods of 1 cpu,
+// so that about two thirds of the nodes could be scaled down.
+func newWorld(numNodes, podsPerNode int) []kruntime.Object {
+	objs := []kruntime.Object{
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: metav1.NamespaceDefault}},
+	}
+
+	for i := 0; i < numNodes; i++ {
+		name := fmt.Sprintf("node-%05d", i)
+		objs = append(objs, newNode(name, fmt.Sprintf("zone-%d", i%3)))
+		objs = append(objs, newPod(fmt.Sprintf("ds-%05d", i), name, "DaemonSet", "100m"))
+		for j := 0; j < podsPerNode; j++ {
+			objs = append(objs, newPod(fmt.Sprintf("pod-%05d-%02d", i, j), name, "ReplicaSet", "1"))
+		}
+	}
+
+	return objs
+}
+
+func newNode(name, zone string) *corev1.Node {
+	allocatable := corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("32"),
+		corev1.ResourceMemory: resource.MustParse("128Gi"),
+		corev1.ResourcePods:   resource.MustParse("110"),
+	}
+
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Labels: map[string]string{
+				corev1.LabelHostname:     name,
+				corev1.LabelTopologyZone: zone,
+			},
+		},
+		Status: corev1.NodeStatus{
+			Capacity:    allocatable,
+			Allocatable: allocatable,
+			Conditions: []corev1.NodeCondition{
+				{Type: corev1.NodeReady, Status: corev1.ConditionTrue},
+			},
+		},
+	}
+}
+
+func newPod(name, nodeName, ownerKind, cpu string) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: metav1.NamespaceDefault,
+			UID:       types.UID("uid-" + name),
+		},
+		Spec: corev1.PodSpec{
+			NodeName:      nodeName,
+			SchedulerName: corev1.DefaultSchedulerName,
+			Containers: []corev1.Container{
+				{
+					Name:  "app",
+					Image: "nginx",
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse(cpu),
+							corev1.ResourceMemory: resource.MustParse("1Gi"),
+						},
+					},
+				},
+			},
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+		},
+	}
+	if len(ownerKind) > 0 {
+		pod.OwnerReferences = []metav1.OwnerReference{
+			{Kind: ownerKind, Name: "owner", UID: "uid-owner"},
+		}
+	}
+
+	return pod
+}
@@ -0,0 +1,57 @@
+/*
+Copyright © 2023 k-cloud-labs org
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"strconv"
+	"testing"
+)
+
+// scales are the number of nodes of the synthetic worlds benchmarked, the flags of the tool are passed after -args, e.g.
+//
+//	go test ./hack/tools/benchmark -run xxx -bench Store/1000 -benchmem -args --max-limit 100
+var scales = []int{1000, 5000, 10000}
+
+func BenchmarkStore(b *testing.B) {
+	benchmarkSuite(b, runStore)
+}
+
+func BenchmarkClusterCompression(b *testing.B) {
+	benchmarkSuite(b, runClusterCompression)
+}
+
+func BenchmarkCapacityEstimation(b *testing.B) {
+	benchmarkSuite(b, runCapacityEstimation)
+}
+
+func benchmarkSuite(b *testing.B, run suite) {
+	for _, numNodes := range scales {
+		b.Run(strconv.Itoa(numNodes), func(b *testing.B) {
+			objs := newWorld(numNodes, *podsPerNode)
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				result, err := run(objs)
+				if err != nil {
+					b.Fatal(err)
+				}
+				b.Log(result)
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// the deep copy functions are written by hand in the same way as deepcopy-gen, keep them updated with the types.
+
+func (in *CapacityEstimationReview) DeepCopyInto(out *CapacityEstimationReview) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+func (in *CapacityEstimationReview) DeepCopy() *CapacityEstimationReview {
+	if in == nil {
+		return nil
+	}
+	out := new(CapacityEstimationReview)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *CapacityEstimationReview) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+func (in *CapacityEstimationReviewSpec) DeepCopyInto(out *CapacityEstimationReviewSpec) {
+	*out = *in
+	if in.Templates != nil {
+		out.Templates = make([]corev1.PodTemplateSpec, len(in.Templates))
+		for i := range in.Templates {
+			in.Templates[i].DeepCopyInto(&out.Templates[i])
+		}
+	}
+	if in.ExcludeNodes != nil {
+		out.ExcludeNodes = make([]string, len(in.ExcludeNodes))
+		copy(out.ExcludeNodes, in.ExcludeNodes)
+	}
+	if in.Interval != nil {
+		out.Interval = new(metav1.Duration)
+		*out.Interval = *in.Interval
+	}
+}
+
+func (in *CapacityEstimationReviewStatus) DeepCopyInto(out *CapacityEstimationReviewStatus) {
+	*out = *in
+	if in.LastRunTime != nil {
+		out.LastRunTime = in.LastRunTime.DeepCopy()
+	}
+	if in.Results != nil {
+		out.Results = make([]CapacityEstimationResult, len(in.Results))
+		for i := range in.Results {
+			in.Results[i].DeepCopyInto(&out.Results[i])
+		}
+	}
+	if in.Warnings != nil {
+		out.Warnings = make([]string, len(in.Warnings))
+		copy(out.Warnings, in.Warnings)
+	}
+	if in.Conditions != nil {
+		out.Conditions = make([]metav1.Condition, len(in.Conditions))
+		for i := range in.Conditions {
+			in.Conditions[i].DeepCopyInto(&out.Conditions[i])
+		}
+	}
+}
+
+func (in *CapacityEstimationResult) DeepCopyInto(out *CapacityEstimationResult) {
+	*out = *in
+	out.StopReason = in.StopReason
+	if in.ReplicasOnNodes != nil {
+		out.ReplicasOnNodes = make([]ReplicasOnNode, len(in.ReplicasOnNodes))
+		copy(out.ReplicasOnNodes, in.ReplicasOnNodes)
+	}
+}
+
+func (in *CapacityEstimationReviewList) DeepCopyInto(out *CapacityEstimationReviewList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		out.Items = make([]CapacityEstimationReview, len(in.Items))
+		for i := range in.Items {
+			in.Items[i].DeepCopyInto(&out.Items[i])
+		}
+	}
+}
+
+func (in *CapacityEstimationReviewList) DeepCopy() *CapacityEstimationReviewList {
+	if in == nil {
+		return nil
+	}
+	out := new(CapacityEstimationReviewList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *CapacityEstimationReviewList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+func (in *ClusterCompressionReview) DeepCopyInto(out *ClusterCompressionReview) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+func (in *ClusterCompressionReview) DeepCopy() *ClusterCompressionReview {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterCompressionReview)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *ClusterCompressionReview) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+func (in *ClusterCompressionReviewSpec) DeepCopyInto(out *ClusterCompressionReviewSpec) {
+	*out = *in
+	if in.ExcludeNodes != nil {
+		out.ExcludeNodes = make([]string, len(in.ExcludeNodes))
+		copy(out.ExcludeNodes, in.ExcludeNodes)
+	}
+	if in.FilterNodeOptions != nil {
+		out.FilterNodeOptions = new(FilterNodeOptions)
+		*out.FilterNodeOptions = *in.FilterNodeOptions
+	}
+	if in.Interval != nil {
+		out.Interval = new(metav1.Duration)
+		*out.Interval = *in.Interval
+	}
+}
+
+func (in *ClusterCompressionReviewStatus) DeepCopyInto(out *ClusterCompressionReviewStatus) {
+	*out = *in
+	if in.LastRunTime != nil {
+		out.LastRunTime = in.LastRunTime.DeepCopy()
+	}
+	if in.ScaleDownNodeNames != nil {
+		out.ScaleDownNodeNames = make([]string, len(in.ScaleDownNodeNames))
+		copy(out.ScaleDownNodeNames, in.ScaleDownNodeNames)
+	}
+	if in.StopReason != nil {
+		out.StopReason = new(StopReason)
+		*out.StopReason = *in.StopReason
+	}
+	if in.Warnings != nil {
+		out.Warnings = make([]string, len(in.Warnings))
+		copy(out.Warnings, in.Warnings)
+	}
+	if in.Conditions != nil {
+		out.Conditions = make([]metav1.Condition, len(in.Conditions))
+		for i := range in.Conditions {
+			in.Conditions[i].DeepCopyInto(&out.Conditions[i])
+		}
+	}
+}
+
+func (in *ClusterCompressionReviewList) DeepCopyInto(out *ClusterCompressionReviewList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		out.Items = make([]ClusterCompressionReview, len(in.Items))
+		for i := range in.Items {
+			in.Items[i].DeepCopyInto(&out.Items[i])
+		}
+	}
+}
+
+func (in *ClusterCompressionReviewList) DeepCopy() *ClusterCompressionReviewList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterCompressionReviewList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *ClusterCompressionReviewList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
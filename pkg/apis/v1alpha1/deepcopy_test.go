@@ -0,0 +1,95 @@
+package v1alpha1
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	fuzz "github.com/google/gofuzz"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// TestDeepCopy checks the hand-written deep copies copy every field and share no memory with the original.
+func TestDeepCopy(t *testing.T) {
+	tests := []runtime.Object{
+		&CapacityEstimationReview{},
+		&CapacityEstimationReviewList{},
+		&ClusterCompressionReview{},
+		&ClusterCompressionReviewList{},
+	}
+
+	// every pointer, slice and map is filled so that a field missed by the copy is found
+	fuzzer := fuzz.NewWithSeed(1).NilChance(0).NumElements(1, 2)
+	for _, obj := range tests {
+		t.Run(reflect.TypeOf(obj).Elem().Name(), func(t *testing.T) {
+			for i := 0; i < 10; i++ {
+				in := obj.DeepCopyObject()
+				fuzzer.Fuzz(in)
+
+				out := in.DeepCopyObject()
+				if !apiequality.Semantic.DeepEqual(in, out) {
+					t.Fatalf("expected the copy equal to the original, got %+v and %+v", out, in)
+				}
+				if err := checkNoAliasing("", reflect.ValueOf(in), reflect.ValueOf(out)); err != nil {
+					t.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// checkNoAliasing returns an error if any pointer, slice or map of in is shared by out, unexported fields are skipped
+// since they could not be set by the copy.
+func checkNoAliasing(path string, in, out reflect.Value) error {
+	switch in.Kind() {
+	case reflect.Pointer:
+		if in.IsNil() || out.IsNil() {
+			return nil
+		}
+		if in.Pointer() == out.Pointer() {
+			return fmt.Errorf("%s is shared by the copy", path)
+		}
+		return checkNoAliasing(path, in.Elem(), out.Elem())
+	case reflect.Interface:
+		if in.IsNil() || out.IsNil() {
+			return nil
+		}
+		return checkNoAliasing(path, in.Elem(), out.Elem())
+	case reflect.Slice:
+		if in.Len() > 0 && out.Len() > 0 && in.Pointer() == out.Pointer() {
+			return fmt.Errorf("%s is shared by the copy", path)
+		}
+		for i := 0; i < in.Len() && i < out.Len(); i++ {
+			if err := checkNoAliasing(fmt.Sprintf("%s[%d]", path, i), in.Index(i), out.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if in.IsNil() || out.IsNil() {
+			return nil
+		}
+		if in.Pointer() == out.Pointer() {
+			return fmt.Errorf("%s is shared by the copy", path)
+		}
+		iter := in.MapRange()
+		for iter.Next() {
+			if value := out.MapIndex(iter.Key()); value.IsValid() {
+				if err := checkNoAliasing(fmt.Sprintf("%s[%v]", path, iter.Key()), iter.Value(), value); err != nil {
+					return err
+				}
+			}
+		}
+	case reflect.Struct:
+		for i := 0; i < in.NumField(); i++ {
+			if !in.Type().Field(i).IsExported() {
+				continue
+			}
+			if err := checkNoAliasing(path+"."+in.Type().Field(i).Name, in.Field(i), out.Field(i)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,23 @@
+// Package v1alpha1 contains the CRDs reconciled by the controller mode of kluster-capacity.
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/scheme"
+)
+
+var (
+	// GroupVersion is the group version of the CRDs, it's the same as the snapshot file format
+	GroupVersion = schema.GroupVersion{Group: "kluster-capacity.k-cloud-labs.io", Version: "v1alpha1"}
+
+	// SchemeBuilder adds the CRDs to a scheme
+	SchemeBuilder = &scheme.Builder{GroupVersion: GroupVersion}
+
+	// AddToScheme adds the CRDs to a scheme
+	AddToScheme = SchemeBuilder.AddToScheme
+)
+
+func init() {
+	SchemeBuilder.Register(&CapacityEstimationReview{}, &CapacityEstimationReviewList{},
+		&ClusterCompressionReview{}, &ClusterCompressionReviewList{})
+}
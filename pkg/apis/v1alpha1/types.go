@@ -0,0 +1,129 @@
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ConditionCompleted is true if the last simulation succeeded, the reason and message tell why if it failed
+	ConditionCompleted = "Completed"
+
+	ReasonSucceeded = "Succeeded"
+	ReasonFailed    = "Failed"
+	// ReasonIncomplete means the simulation is stopped by the timeout or interrupted, the result is partial
+	ReasonIncomplete = "Incomplete"
+)
+
+// CapacityEstimationReview requests a capacity estimation of the pod templates, the result is written into the status.
+type CapacityEstimationReview struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CapacityEstimationReviewSpec   `json:"spec"`
+	Status CapacityEstimationReviewStatus `json:"status,omitempty"`
+}
+
+type CapacityEstimationReviewSpec struct {
+	// pod templates to estimate, each of them is simulated separately. pods are created in the namespace of the review
+	// if the namespace of the template is empty.
+	Templates []corev1.PodTemplateSpec `json:"templates"`
+	// max number of replicas of each template, unlimited if zero
+	MaxLimit     int      `json:"maxLimit,omitempty"`
+	ExcludeNodes []string `json:"excludeNodes,omitempty"`
+	// preempt lower priority pods when the pod could not be scheduled in free space
+	EnablePreemption bool `json:"enablePreemption,omitempty"`
+	// the review is simulated again on each interval if set, otherwise only when the spec is changed
+	Interval *metav1.Duration `json:"interval,omitempty"`
+}
+
+type CapacityEstimationReviewStatus struct {
+	// generation of the spec simulated last time
+	ObservedGeneration int64        `json:"observedGeneration,omitempty"`
+	LastRunTime        *metav1.Time `json:"lastRunTime,omitempty"`
+	// result of each template, in the same order as the templates
+	Results []CapacityEstimationResult `json:"results,omitempty"`
+	// problems which may affect the result
+	Warnings   []string           `json:"warnings,omitempty"`
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+type CapacityEstimationResult struct {
+	// name of the pod template
+	Template string `json:"template"`
+	// actual number of replicas that could schedule
+	Replicas   int32      `json:"replicas"`
+	StopReason StopReason `json:"stopReason"`
+	// numbers of replicas on nodes
+	ReplicasOnNodes []ReplicasOnNode `json:"replicasOnNodes,omitempty"`
+}
+
+type ReplicasOnNode struct {
+	NodeName string `json:"nodeName"`
+	Replicas int    `json:"replicas"`
+}
+
+type StopReason struct {
+	StopType    string `json:"stopType"`
+	StopMessage string `json:"stopMessage"`
+}
+
+// CapacityEstimationReviewList is a list of CapacityEstimationReview.
+type CapacityEstimationReviewList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CapacityEstimationReview `json:"items"`
+}
+
+// ClusterCompressionReview requests a cluster compression, the nodes could be scaled down are written into the status.
+type ClusterCompressionReview struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ClusterCompressionReviewSpec   `json:"spec,omitempty"`
+	Status ClusterCompressionReviewStatus `json:"status,omitempty"`
+}
+
+type ClusterCompressionReviewSpec struct {
+	// max number of nodes to scale down, unlimited if zero
+	MaxLimit     int      `json:"maxLimit,omitempty"`
+	ExcludeNodes []string `json:"excludeNodes,omitempty"`
+	// rules to select the nodes to scale down, not ready nodes and tainted nodes are excluded if not set
+	FilterNodeOptions *FilterNodeOptions `json:"filterNodeOptions,omitempty"`
+	// the review is simulated again on each interval if set, otherwise only when the spec is changed
+	Interval *metav1.Duration `json:"interval,omitempty"`
+}
+
+type FilterNodeOptions struct {
+	ExcludeNotReadyNode bool `json:"excludeNotReadyNode,omitempty"`
+	ExcludeTaintNode    bool `json:"excludeTaintNode,omitempty"`
+	IgnoreStaticPod     bool `json:"ignoreStaticPod,omitempty"`
+	IgnoreMirrorPod     bool `json:"ignoreMirrorPod,omitempty"`
+	IgnoreCloneSet      bool `json:"ignoreCloneSet,omitempty"`
+	IgnoreVolumePod     bool `json:"ignoreVolumePod,omitempty"`
+	// applies the same rules as cluster-autoscaler to decide whether a node could be scaled down
+	ClusterAutoscalerCompatible bool `json:"clusterAutoscalerCompatible,omitempty"`
+}
+
+type ClusterCompressionReviewStatus struct {
+	// generation of the spec simulated last time
+	ObservedGeneration int64        `json:"observedGeneration,omitempty"`
+	LastRunTime        *metav1.Time `json:"lastRunTime,omitempty"`
+	// nodes could be scaled down
+	ScaleDownNodeNames   []string    `json:"scaleDownNodeNames,omitempty"`
+	ScaleDownNodeCount   int         `json:"scaleDownNodeCount"`
+	SelectNodeCount      int         `json:"selectNodeCount,omitempty"`
+	SchedulerCount       int         `json:"schedulerCount,omitempty"`
+	FailedSchedulerCount int         `json:"failedSchedulerCount,omitempty"`
+	StopReason           *StopReason `json:"stopReason,omitempty"`
+	// problems which may affect the result
+	Warnings   []string           `json:"warnings,omitempty"`
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// ClusterCompressionReviewList is a list of ClusterCompressionReview.
+type ClusterCompressionReviewList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterCompressionReview `json:"items"`
+}
@@ -4,3 +4,20 @@ const (
 	PodProvisioner = "kc.k-cloud-labs.io/provisioned-by"
 	SchedulerName  = "simulator-scheduler"
 )
+
+// names of the supported scheduler frameworks
+const (
+	FrameworkKubeScheduler = "kube-scheduler"
+	FrameworkVolcano       = "volcano"
+)
+
+// types of the stop reason of a simulation which is stopped before it finishes, the result is partial
+const (
+	StopTypeTimeout     = "Timeout"
+	StopTypeInterrupted = "Interrupted"
+)
+
+// IsIncomplete returns whether the simulation stopped with stopType didn't finish.
+func IsIncomplete(stopType string) bool {
+	return stopType == StopTypeTimeout || stopType == StopTypeInterrupted
+}
@@ -0,0 +1,137 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/k-cloud-labs/kluster-capacity/pkg/apis/v1alpha1"
+	"github.com/k-cloud-labs/kluster-capacity/pkg/simulator/capacityestimation"
+)
+
+// CapacityEstimationReconciler estimates the capacity of the templates of CapacityEstimationReview.
+type CapacityEstimationReconciler struct {
+	client.Client
+	Options *Options
+}
+
+func (r *CapacityEstimationReconciler) SetupWithManager(mgr manager.Manager) error {
+	// updates of the status are ignored, the review is requeued by itself if it has an interval
+	return builder.ControllerManagedBy(mgr).
+		For(&v1alpha1.CapacityEstimationReview{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Complete(r)
+}
+
+func (r *CapacityEstimationReconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	review := &v1alpha1.CapacityEstimationReview{}
+	if err := r.Get(ctx, req.NamespacedName, review); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(err)
+	}
+
+	wait := untilNextRun(review.Generation, review.Status.ObservedGeneration, review.Status.LastRunTime, review.Spec.Interval, time.Now())
+	if wait < 0 {
+		return reconcile.Result{}, nil
+	} else if wait > 0 {
+		return reconcile.Result{RequeueAfter: wait}, nil
+	}
+
+	// failing to load the world is retried, while failing to simulate is reported in the status
+	options, err := r.Options.simulatorOptions(review.Spec.MaxLimit, review.Spec.ExcludeNodes)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	klog.V(2).InfoS("Estimate capacity", "review", req.NamespacedName)
+	pods := templatesToPods(review)
+	simulateCtx, cancel := r.Options.context(ctx)
+	reviews, err := capacityestimation.Estimate(simulateCtx, &capacityestimation.Config{
+		Options:          options,
+		Pods:             pods,
+		EnablePreemption: review.Spec.EnablePreemption,
+	})
+	cancel()
+
+	now := metav1.Now()
+	review.Status.ObservedGeneration = review.Generation
+	review.Status.LastRunTime = &now
+	review.Status.Results = nil
+	review.Status.Warnings = nil
+	if err == nil {
+		// warnings of the world are reported by each template
+		warnings := sets.NewString()
+		for i, ce := range reviews {
+			review.Status.Results = append(review.Status.Results, capacityEstimationResult(pods[i].Name, ce))
+			for _, warning := range ce.Status.Warnings {
+				if !warnings.Has(warning) {
+					warnings.Insert(warning)
+					review.Status.Warnings = append(review.Status.Warnings, warning)
+				}
+			}
+		}
+	}
+	stopReasons := make([]v1alpha1.StopReason, 0, len(review.Status.Results))
+	for _, result := range review.Status.Results {
+		stopReasons = append(stopReasons, result.StopReason)
+	}
+	setCompleted(&review.Status.Conditions, review.Generation, err, stopReasons...)
+
+	if err := r.Status().Update(ctx, review); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	return reconcile.Result{RequeueAfter: requeueAfter(review.Spec.Interval)}, nil
+}
+
+// templatesToPods returns the pods to estimate, pods without namespace or name are named after the review.
+func templatesToPods(review *v1alpha1.CapacityEstimationReview) []*corev1.Pod {
+	pods := make([]*corev1.Pod, 0, len(review.Spec.Templates))
+	for i := range review.Spec.Templates {
+		template := review.Spec.Templates[i].DeepCopy()
+		pod := &corev1.Pod{
+			ObjectMeta: template.ObjectMeta,
+			Spec:       template.Spec,
+		}
+		if len(pod.Namespace) == 0 {
+			pod.Namespace = review.Namespace
+		}
+		if len(pod.Name) == 0 {
+			pod.Name = fmt.Sprintf("%s-%d", review.Name, i)
+		}
+		pods = append(pods, pod)
+	}
+
+	return pods
+}
+
+func capacityEstimationResult(template string, review *capacityestimation.CapacityEstimationReview) v1alpha1.CapacityEstimationResult {
+	result := v1alpha1.CapacityEstimationResult{
+		Template: template,
+		Replicas: review.Status.Replicas,
+	}
+	if review.Status.StopReason != nil {
+		result.StopReason = v1alpha1.StopReason{
+			StopType:    review.Status.StopReason.StopType,
+			StopMessage: review.Status.StopReason.StopMessage,
+		}
+	}
+	for _, pod := range review.Status.Pods {
+		for _, replicas := range pod.ReplicasOnNodes {
+			result.ReplicasOnNodes = append(result.ReplicasOnNodes, v1alpha1.ReplicasOnNode{
+				NodeName: replicas.NodeName,
+				Replicas: replicas.Replicas,
+			})
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,63 @@
+package controller
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/k-cloud-labs/kluster-capacity/pkg/apis/v1alpha1"
+)
+
+func TestTemplatesToPods(t *testing.T) {
+	review := &v1alpha1.CapacityEstimationReview{
+		ObjectMeta: metav1.ObjectMeta{Name: "review", Namespace: "team"},
+		Spec: v1alpha1.CapacityEstimationReviewSpec{
+			Templates: []corev1.PodTemplateSpec{
+				{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "web"}},
+					Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "web", Image: "nginx"}}},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "storage"},
+					Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "db", Image: "mysql"}}},
+				},
+			},
+		},
+	}
+
+	pods := templatesToPods(review)
+
+	want := []struct {
+		namespace string
+		name      string
+		container string
+	}{
+		// named after the review and its index
+		{namespace: "team", name: "review-0", container: "web"},
+		// the namespace and the name of the template are kept
+		{namespace: "storage", name: "db", container: "db"},
+	}
+	if len(pods) != len(want) {
+		t.Fatalf("expected %d pods, got %d", len(want), len(pods))
+	}
+	for i, w := range want {
+		pod := pods[i]
+		if pod.Namespace != w.namespace || pod.Name != w.name || pod.Spec.Containers[0].Name != w.container {
+			t.Errorf("expected pod %s/%s with container %s, got %s/%s with %v", w.namespace, w.name, w.container, pod.Namespace, pod.Name, pod.Spec.Containers)
+		}
+	}
+	if pods[0].Labels["app"] != "web" {
+		t.Errorf("expected the labels of the template kept, got %v", pods[0].Labels)
+	}
+
+	// the review in the cache must not be changed by the simulation
+	pods[0].Labels["app"] = "changed"
+	pods[1].Spec.Containers[0].Image = "changed"
+	if review.Spec.Templates[0].Labels["app"] != "web" || review.Spec.Templates[1].Spec.Containers[0].Image != "mysql" {
+		t.Errorf("expected the templates of the review not changed, got %+v", review.Spec.Templates)
+	}
+	if len(review.Spec.Templates[0].Name) != 0 || len(review.Spec.Templates[0].Namespace) != 0 {
+		t.Errorf("expected the template not named, got %s/%s", review.Spec.Templates[0].Namespace, review.Spec.Templates[0].Name)
+	}
+}
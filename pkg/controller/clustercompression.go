@@ -0,0 +1,111 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/k-cloud-labs/kluster-capacity/pkg/apis/v1alpha1"
+	"github.com/k-cloud-labs/kluster-capacity/pkg/simulator/clustercompression"
+)
+
+// ClusterCompressionReconciler finds the nodes could be scaled down for ClusterCompressionReview.
+type ClusterCompressionReconciler struct {
+	client.Client
+	Options *Options
+}
+
+func (r *ClusterCompressionReconciler) SetupWithManager(mgr manager.Manager) error {
+	// updates of the status are ignored, the review is requeued by itself if it has an interval
+	return builder.ControllerManagedBy(mgr).
+		For(&v1alpha1.ClusterCompressionReview{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Complete(r)
+}
+
+func (r *ClusterCompressionReconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	review := &v1alpha1.ClusterCompressionReview{}
+	if err := r.Get(ctx, req.NamespacedName, review); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(err)
+	}
+
+	wait := untilNextRun(review.Generation, review.Status.ObservedGeneration, review.Status.LastRunTime, review.Spec.Interval, time.Now())
+	if wait < 0 {
+		return reconcile.Result{}, nil
+	} else if wait > 0 {
+		return reconcile.Result{RequeueAfter: wait}, nil
+	}
+
+	// failing to load the world is retried, while failing to simulate is reported in the status
+	options, err := r.Options.simulatorOptions(review.Spec.MaxLimit, review.Spec.ExcludeNodes)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	klog.V(2).InfoS("Compress cluster", "review", req.NamespacedName)
+	simulateCtx, cancel := r.Options.context(ctx)
+	cc, err := clustercompression.Compress(simulateCtx, &clustercompression.Config{
+		Options:           options,
+		FilterNodeOptions: filterNodeOptions(review.Spec.FilterNodeOptions),
+	})
+	cancel()
+
+	now := metav1.Now()
+	status := v1alpha1.ClusterCompressionReviewStatus{
+		ObservedGeneration: review.Generation,
+		LastRunTime:        &now,
+		Conditions:         review.Status.Conditions,
+	}
+	if err == nil {
+		status.ScaleDownNodeNames = cc.Status.ScaleDownNodeNames
+		status.ScaleDownNodeCount = len(cc.Status.ScaleDownNodeNames)
+		status.SelectNodeCount = cc.Status.SelectNodeCount
+		status.SchedulerCount = cc.Status.SchedulerCount
+		status.FailedSchedulerCount = cc.Status.FailedSchedulerCount
+		status.Warnings = cc.Status.Warnings
+		if cc.Status.StopReason != nil {
+			status.StopReason = &v1alpha1.StopReason{
+				StopType:    cc.Status.StopReason.StopType,
+				StopMessage: cc.Status.StopReason.StopMessage,
+			}
+		}
+	}
+	var stopReasons []v1alpha1.StopReason
+	if status.StopReason != nil {
+		stopReasons = append(stopReasons, *status.StopReason)
+	}
+	setCompleted(&status.Conditions, review.Generation, err, stopReasons...)
+	review.Status = status
+
+	if err := r.Status().Update(ctx, review); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	return reconcile.Result{RequeueAfter: requeueAfter(review.Spec.Interval)}, nil
+}
+
+// filterNodeOptions returns the same defaults as the flags of cc if options is nil.
+func filterNodeOptions(options *v1alpha1.FilterNodeOptions) clustercompression.FilterNodeOptions {
+	if options == nil {
+		return clustercompression.FilterNodeOptions{
+			ExcludeNotReadyNode: true,
+			ExcludeTaintNode:    true,
+		}
+	}
+
+	return clustercompression.FilterNodeOptions{
+		ExcludeNotReadyNode:         options.ExcludeNotReadyNode,
+		ExcludeTaintNode:            options.ExcludeTaintNode,
+		IgnoreStaticPod:             options.IgnoreStaticPod,
+		IgnoreMirrorPod:             options.IgnoreMirrorPod,
+		IgnoreCloneSet:              options.IgnoreCloneSet,
+		IgnoreVolumePod:             options.IgnoreVolumePod,
+		ClusterAutoscalerCompatible: options.ClusterAutoscalerCompatible,
+	}
+}
@@ -0,0 +1,106 @@
+// Package controller reconciles the review CRDs, the simulation is run when a review is created, its spec is changed
+// or its interval is reached, and the result is written into the status of the review.
+package controller
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/k-cloud-labs/kluster-capacity/pkg"
+	"github.com/k-cloud-labs/kluster-capacity/pkg/apis/v1alpha1"
+	"github.com/k-cloud-labs/kluster-capacity/pkg/simulator"
+)
+
+// Options are shared by all reconcilers.
+type Options struct {
+	// loads the world before each simulation so that the result is up to date
+	Loader simulator.Loader
+	// template of the options of each simulation, InitObjs is replaced by the loaded world
+	SimulatorOptions simulator.Options
+	// max duration of each simulation, unlimited if zero
+	Timeout time.Duration
+}
+
+// simulatorOptions loads the world and returns the options of a simulation against it.
+func (o *Options) simulatorOptions(maxLimit int, excludeNodes []string) (simulator.Options, error) {
+	objs, err := o.Loader()
+	if err != nil {
+		return simulator.Options{}, err
+	}
+
+	options := o.SimulatorOptions
+	options.Source = simulator.SourceObjects
+	options.InitObjs = objs
+	if maxLimit > 0 {
+		options.MaxLimit = maxLimit
+	}
+	options.ExcludeNodes = append(append([]string(nil), options.ExcludeNodes...), excludeNodes...)
+
+	return options, nil
+}
+
+// context returns the context of a simulation with the timeout.
+func (o *Options) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	if o.Timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, o.Timeout)
+}
+
+// untilNextRun returns how long to wait until the review is simulated again, zero means it should be simulated now
+// and negative means it's never simulated again unless the spec is changed.
+func untilNextRun(generation, observedGeneration int64, lastRunTime *metav1.Time, interval *metav1.Duration, now time.Time) time.Duration {
+	if lastRunTime == nil || generation != observedGeneration {
+		return 0
+	}
+
+	if interval == nil || interval.Duration <= 0 {
+		return -1
+	}
+
+	if wait := lastRunTime.Add(interval.Duration).Sub(now); wait > 0 {
+		return wait
+	}
+
+	return 0
+}
+
+// requeueAfter returns the delay to simulate the review again after it's simulated, zero means never.
+func requeueAfter(interval *metav1.Duration) time.Duration {
+	if interval == nil || interval.Duration <= 0 {
+		return 0
+	}
+
+	return interval.Duration
+}
+
+// setCompleted sets the Completed condition by the error and the stop reasons of the simulation, the condition is false
+// if the simulation is stopped before it finishes although the partial result is reported.
+func setCompleted(conditions *[]metav1.Condition, generation int64, err error, stopReasons ...v1alpha1.StopReason) {
+	condition := metav1.Condition{
+		Type:               v1alpha1.ConditionCompleted,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: generation,
+		Reason:             v1alpha1.ReasonSucceeded,
+	}
+	if err != nil {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = v1alpha1.ReasonFailed
+		condition.Message = err.Error()
+	} else {
+		for _, reason := range stopReasons {
+			if pkg.IsIncomplete(reason.StopType) {
+				condition.Status = metav1.ConditionFalse
+				condition.Reason = v1alpha1.ReasonIncomplete
+				condition.Message = reason.StopType + ": " + reason.StopMessage
+				break
+			}
+		}
+	}
+
+	meta.SetStatusCondition(conditions, condition)
+}
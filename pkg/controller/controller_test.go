@@ -0,0 +1,146 @@
+package controller
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/k-cloud-labs/kluster-capacity/pkg"
+	"github.com/k-cloud-labs/kluster-capacity/pkg/apis/v1alpha1"
+)
+
+func TestUntilNextRun(t *testing.T) {
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	lastRunTime := &metav1.Time{Time: now.Add(-time.Minute)}
+
+	tests := []struct {
+		name               string
+		generation         int64
+		observedGeneration int64
+		lastRunTime        *metav1.Time
+		interval           *metav1.Duration
+		want               time.Duration
+	}{
+		{
+			name:       "never run",
+			generation: 1,
+			interval:   &metav1.Duration{Duration: time.Hour},
+			want:       0,
+		},
+		{
+			name:               "spec changed",
+			generation:         2,
+			observedGeneration: 1,
+			lastRunTime:        lastRunTime,
+			interval:           &metav1.Duration{Duration: time.Hour},
+			want:               0,
+		},
+		{
+			name:               "run without interval",
+			generation:         1,
+			observedGeneration: 1,
+			lastRunTime:        lastRunTime,
+			want:               -1,
+		},
+		{
+			name:               "run with zero interval",
+			generation:         1,
+			observedGeneration: 1,
+			lastRunTime:        lastRunTime,
+			interval:           &metav1.Duration{},
+			want:               -1,
+		},
+		{
+			name:               "interval not reached",
+			generation:         1,
+			observedGeneration: 1,
+			lastRunTime:        lastRunTime,
+			interval:           &metav1.Duration{Duration: time.Hour},
+			want:               59 * time.Minute,
+		},
+		{
+			name:               "interval reached",
+			generation:         1,
+			observedGeneration: 1,
+			lastRunTime:        lastRunTime,
+			interval:           &metav1.Duration{Duration: time.Minute},
+			want:               0,
+		},
+		{
+			name:               "interval passed",
+			generation:         1,
+			observedGeneration: 1,
+			lastRunTime:        lastRunTime,
+			interval:           &metav1.Duration{Duration: time.Second},
+			want:               0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := untilNextRun(tt.generation, tt.observedGeneration, tt.lastRunTime, tt.interval, now); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSetCompleted(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		stopReasons []v1alpha1.StopReason
+		wantStatus  metav1.ConditionStatus
+		wantReason  string
+	}{
+		{
+			name:        "succeeded",
+			stopReasons: []v1alpha1.StopReason{{StopType: "LimitReached", StopMessage: "limit reached"}},
+			wantStatus:  metav1.ConditionTrue,
+			wantReason:  v1alpha1.ReasonSucceeded,
+		},
+		{
+			name:       "failed",
+			err:        fmt.Errorf("no nodes"),
+			wantStatus: metav1.ConditionFalse,
+			wantReason: v1alpha1.ReasonFailed,
+		},
+		{
+			name: "timeout of any template",
+			stopReasons: []v1alpha1.StopReason{
+				{StopType: "LimitReached", StopMessage: "limit reached"},
+				{StopType: pkg.StopTypeTimeout, StopMessage: "simulation did not finish before the deadline"},
+			},
+			wantStatus: metav1.ConditionFalse,
+			wantReason: v1alpha1.ReasonIncomplete,
+		},
+		{
+			name:        "interrupted",
+			stopReasons: []v1alpha1.StopReason{{StopType: pkg.StopTypeInterrupted, StopMessage: "simulation was interrupted"}},
+			wantStatus:  metav1.ConditionFalse,
+			wantReason:  v1alpha1.ReasonIncomplete,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the condition of the last run is replaced
+			conditions := []metav1.Condition{{Type: v1alpha1.ConditionCompleted, Status: metav1.ConditionUnknown, Reason: "Unknown"}}
+			setCompleted(&conditions, 2, tt.err, tt.stopReasons...)
+
+			if len(conditions) != 1 {
+				t.Fatalf("expected 1 condition, got %v", conditions)
+			}
+			condition := meta.FindStatusCondition(conditions, v1alpha1.ConditionCompleted)
+			if condition.Status != tt.wantStatus || condition.Reason != tt.wantReason || condition.ObservedGeneration != 2 {
+				t.Errorf("expected %s %s of generation 2, got %+v", tt.wantStatus, tt.wantReason, condition)
+			}
+			if tt.wantStatus == metav1.ConditionFalse && len(condition.Message) == 0 {
+				t.Errorf("expected the message of the failure, got %+v", condition)
+			}
+		})
+	}
+}
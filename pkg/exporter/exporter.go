@@ -0,0 +1,283 @@
+// Package exporter runs ce and cc periodically and exposes the results as prometheus metrics, so that alerts could
+// be fired before the capacity runs out.
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	corev1 "k8s.io/api/core/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/klog/v2"
+
+	"github.com/k-cloud-labs/kluster-capacity/pkg"
+	"github.com/k-cloud-labs/kluster-capacity/pkg/simulator"
+	"github.com/k-cloud-labs/kluster-capacity/pkg/simulator/capacityestimation"
+	"github.com/k-cloud-labs/kluster-capacity/pkg/simulator/clustercompression"
+)
+
+const (
+	namespace = "kluster_capacity"
+
+	simulationCE = "ce"
+	simulationCC = "cc"
+
+	MetricsPath = "/metrics"
+)
+
+// Exporter runs ce for each template and cc against the same world on each round.
+type Exporter struct {
+	loader simulator.Loader
+	// template of the options of each simulation, InitObjs is replaced by the loaded world
+	options simulator.Options
+	// pod templates estimated by ce, the names must be unique since they are used as the label
+	templates []*corev1.Pod
+	// whether to run cc, MaxLimit is not applied to cc
+	clusterCompression bool
+	// max duration of each simulation, unlimited if zero
+	timeout time.Duration
+
+	registry                  *prometheus.Registry
+	schedulableReplicas       *prometheus.GaugeVec
+	schedulableReplicasOnNode *replicasOnNodeCollector
+	removableNodes            prometheus.Gauge
+	lastSuccess               *prometheus.GaugeVec
+	failures                  *prometheus.CounterVec
+}
+
+// New creates an exporter which loads the world by loader and estimates templates, options are shared by all
+// simulations.
+func New(loader simulator.Loader, options simulator.Options, templates []*corev1.Pod, clusterCompression bool, timeout time.Duration) *Exporter {
+	e := &Exporter{
+		loader:             loader,
+		options:            options,
+		templates:          templates,
+		clusterCompression: clusterCompression,
+		timeout:            timeout,
+		registry:           prometheus.NewRegistry(),
+		schedulableReplicas: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "schedulable_replicas",
+			Help:      "Number of replicas of the template could be scheduled.",
+		}, []string{"template"}),
+		schedulableReplicasOnNode: &replicasOnNodeCollector{
+			desc: prometheus.NewDesc(
+				prometheus.BuildFQName(namespace, "", "schedulable_replicas_on_node"),
+				"Number of replicas of the template could be scheduled on the node, nodes without replicas are omitted.",
+				[]string{"template", "node"}, nil,
+			),
+		},
+		removableNodes: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "removable_nodes",
+			Help:      "Number of nodes could be scaled down by cluster compression.",
+		}),
+		lastSuccess: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "last_success_timestamp_seconds",
+			Help:      "Unix time of the last successful simulation, the other metrics are stale if it's too old.",
+		}, []string{"simulation"}),
+		failures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "failures_total",
+			Help:      "Number of failed simulations.",
+		}, []string{"simulation"}),
+	}
+
+	e.registry.MustRegister(e.schedulableReplicas, e.schedulableReplicasOnNode, e.lastSuccess, e.failures)
+	if clusterCompression {
+		e.registry.MustRegister(e.removableNodes)
+	}
+
+	return e
+}
+
+// Handler returns the handler serving the metrics.
+func (e *Exporter) Handler() http.Handler {
+	return promhttp.HandlerFor(e.registry, promhttp.HandlerOpts{})
+}
+
+// Collect loads the world and runs the simulations once, the metrics of a failed simulation keep the last values.
+func (e *Exporter) Collect(ctx context.Context) error {
+	objs, err := e.loader()
+	if err != nil {
+		e.failures.WithLabelValues(simulationCE).Inc()
+		if e.clusterCompression {
+			e.failures.WithLabelValues(simulationCC).Inc()
+		}
+		return err
+	}
+
+	options := e.options
+	options.Source = simulator.SourceObjects
+	options.InitObjs = objs
+
+	var errs []error
+	if err := e.collectCapacityEstimation(ctx, options); err != nil {
+		e.failures.WithLabelValues(simulationCE).Inc()
+		errs = append(errs, err)
+	}
+
+	if e.clusterCompression {
+		if err := e.collectClusterCompression(ctx, options); err != nil {
+			e.failures.WithLabelValues(simulationCC).Inc()
+			errs = append(errs, err)
+		}
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+func (e *Exporter) collectCapacityEstimation(ctx context.Context, options simulator.Options) error {
+	ctx, cancel := e.context(ctx)
+	defer cancel()
+
+	reviews, err := capacityestimation.Estimate(ctx, &capacityestimation.Config{
+		Options: options,
+		Pods:    e.templates,
+	})
+	if err != nil {
+		return err
+	}
+	// the replicas of a partial run are less than the real ones, the last values are kept instead
+	for i, review := range reviews {
+		if review.Status.StopReason != nil && pkg.IsIncomplete(review.Status.StopReason.StopType) {
+			return fmt.Errorf("ce of template %s stopped before it finished: %s", e.templates[i].Name, review.Status.StopReason.StopMessage)
+		}
+	}
+
+	var onNodes []replicasOnNode
+	for i, review := range reviews {
+		template := e.templates[i].Name
+		e.schedulableReplicas.WithLabelValues(template).Set(float64(review.Status.Replicas))
+		for _, pod := range review.Status.Pods {
+			for _, replicas := range pod.ReplicasOnNodes {
+				onNodes = append(onNodes, replicasOnNode{template: template, node: replicas.NodeName, replicas: replicas.Replicas})
+			}
+		}
+	}
+	// nodes which are gone or have no replicas any more are dropped
+	e.schedulableReplicasOnNode.set(onNodes)
+	e.lastSuccess.WithLabelValues(simulationCE).SetToCurrentTime()
+
+	return nil
+}
+
+func (e *Exporter) collectClusterCompression(ctx context.Context, options simulator.Options) error {
+	ctx, cancel := e.context(ctx)
+	defer cancel()
+
+	options.MaxLimit = 0
+	review, err := clustercompression.Compress(ctx, &clustercompression.Config{
+		Options: options,
+		// same defaults as the flags of cc
+		FilterNodeOptions: clustercompression.FilterNodeOptions{
+			ExcludeNotReadyNode: true,
+			ExcludeTaintNode:    true,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	// nodes not reviewed yet might be removable too
+	if review.Status.StopReason != nil && pkg.IsIncomplete(review.Status.StopReason.StopType) {
+		return fmt.Errorf("cc stopped before it finished: %s", review.Status.StopReason.StopMessage)
+	}
+
+	e.removableNodes.Set(float64(len(review.Status.ScaleDownNodeNames)))
+	e.lastSuccess.WithLabelValues(simulationCC).SetToCurrentTime()
+
+	return nil
+}
+
+type replicasOnNode struct {
+	template string
+	node     string
+	replicas int
+}
+
+// replicasOnNodeCollector exposes the replicas on nodes of the last round, all series are replaced at once so that
+// a scrape never sees a round half updated.
+type replicasOnNodeCollector struct {
+	desc *prometheus.Desc
+
+	lock    sync.RWMutex
+	onNodes []replicasOnNode
+}
+
+func (c *replicasOnNodeCollector) set(onNodes []replicasOnNode) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.onNodes = onNodes
+}
+
+// Describe implements prometheus.Collector.
+func (c *replicasOnNodeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+// Collect implements prometheus.Collector.
+func (c *replicasOnNodeCollector) Collect(ch chan<- prometheus.Metric) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	for _, r := range c.onNodes {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(r.replicas), r.template, r.node)
+	}
+}
+
+// context returns the context of a simulation with the timeout.
+func (e *Exporter) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	if e.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, e.timeout)
+}
+
+// Run serves the metrics on addr and collects them on each interval until ctx is done, the first round is
+// collected at once.
+func (e *Exporter) Run(ctx context.Context, addr string, interval time.Duration) error {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			start := time.Now()
+			if err := e.Collect(ctx); err != nil {
+				klog.ErrorS(err, "Failed to collect metrics")
+			} else {
+				klog.V(2).InfoS("Metrics collected", "duration", time.Since(start))
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	mux := http.NewServeMux()
+	mux.Handle(MetricsPath, e.Handler())
+	server := &http.Server{Addr: addr, Handler: mux}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	klog.InfoS("Serving metrics", "address", addr, "path", MetricsPath)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,119 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/k-cloud-labs/kluster-capacity/pkg/simulator"
+)
+
+func TestCollectKeepsValuesOfPartialRun(t *testing.T) {
+	numNodes := 2
+	loader := func() ([]runtime.Object, error) {
+		return newTestWorld(numNodes), nil
+	}
+	e := New(loader, simulator.Options{MaxLimit: 100}, []*corev1.Pod{newTestPod("template", "")}, true, 0)
+
+	if err := e.Collect(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	if got := testutil.ToFloat64(e.schedulableReplicas.WithLabelValues("template")); got != 4 {
+		t.Errorf("expected 4 replicas on 2 nodes, got %v", got)
+	}
+	if got := testutil.CollectAndCount(e.schedulableReplicasOnNode); got != 2 {
+		t.Errorf("expected replicas on 2 nodes, got %d series", got)
+	}
+	removable := testutil.ToFloat64(e.removableNodes)
+
+	// the world grows but no simulation could finish before the deadline
+	numNodes = 3
+	e.timeout = time.Nanosecond
+	if err := e.Collect(context.TODO()); err == nil {
+		t.Fatal("expected collect failed by the timeout")
+	}
+	for _, simulation := range []string{simulationCE, simulationCC} {
+		if got := testutil.ToFloat64(e.failures.WithLabelValues(simulation)); got != 1 {
+			t.Errorf("expected 1 failure of %s, got %v", simulation, got)
+		}
+	}
+	if got := testutil.ToFloat64(e.schedulableReplicas.WithLabelValues("template")); got != 4 {
+		t.Errorf("expected 4 replicas kept, got %v", got)
+	}
+	if got := testutil.CollectAndCount(e.schedulableReplicasOnNode); got != 2 {
+		t.Errorf("expected replicas on 2 nodes kept, got %d series", got)
+	}
+	if got := testutil.ToFloat64(e.removableNodes); got != removable {
+		t.Errorf("expected %v removable nodes kept, got %v", removable, got)
+	}
+
+	// the next round finishes in time
+	e.timeout = 0
+	if err := e.Collect(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	if got := testutil.ToFloat64(e.schedulableReplicas.WithLabelValues("template")); got != 6 {
+		t.Errorf("expected 6 replicas on 3 nodes, got %v", got)
+	}
+	if got := testutil.CollectAndCount(e.schedulableReplicasOnNode); got != 3 {
+		t.Errorf("expected replicas on 3 nodes, got %d series", got)
+	}
+}
+
+// newTestWorld returns empty nodes of 2 cpu.
+func newTestWorld(numNodes int) []runtime.Object {
+	objs := []runtime.Object{
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: metav1.NamespaceDefault}},
+	}
+
+	allocatable := corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("2"),
+		corev1.ResourceMemory: resource.MustParse("4Gi"),
+		corev1.ResourcePods:   resource.MustParse("110"),
+	}
+	for i := 0; i < numNodes; i++ {
+		name := fmt.Sprintf("node-%d", i)
+		objs = append(objs, &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{corev1.LabelHostname: name}},
+			Status: corev1.NodeStatus{
+				Capacity:    allocatable,
+				Allocatable: allocatable,
+				Conditions:  []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+			},
+		})
+	}
+
+	return objs
+}
+
+func newTestPod(name, nodeName string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: metav1.NamespaceDefault,
+			UID:       types.UID("uid-" + name),
+		},
+		Spec: corev1.PodSpec{
+			NodeName:      nodeName,
+			SchedulerName: corev1.DefaultSchedulerName,
+			Containers: []corev1.Container{{
+				Name:  "app",
+				Image: "nginx",
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("1"),
+						corev1.ResourceMemory: resource.MustParse("1Gi"),
+					},
+				},
+			}},
+		},
+	}
+}
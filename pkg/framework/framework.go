@@ -0,0 +1,39 @@
+package framework
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	restclient "k8s.io/client-go/rest"
+	schedconfig "k8s.io/kubernetes/cmd/kube-scheduler/app/config"
+
+	"github.com/k-cloud-labs/kluster-capacity/pkg"
+)
+
+// New creates the framework specified by name, kube-scheduler is used if name is empty.
+func New(name string, kubeSchedulerConfig *schedconfig.CompletedConfig, restConfig *restclient.Config, options ...Option) (pkg.Framework, error) {
+	switch name {
+	case "", pkg.FrameworkKubeScheduler:
+		return NewKubeSchedulerFramework(kubeSchedulerConfig, restConfig, options...)
+	case pkg.FrameworkVolcano:
+		return NewVolcanoFramework(kubeSchedulerConfig, restConfig, options...)
+	default:
+		return nil, fmt.Errorf("unsupported framework %s", name)
+	}
+}
+
+// InitError is returned by Initialize when the world could not be copied from the cluster.
+type InitError struct {
+	GVK schema.GroupVersionKind
+	// Op is the operation failed, e.g. list
+	Op  string
+	Err error
+}
+
+func (e *InitError) Error() string {
+	return fmt.Sprintf("unable to %s %s: %v", e.Op, e.GVK.String(), e.Err)
+}
+
+func (e *InitError) Unwrap() error {
+	return e.Err
+}
@@ -27,12 +27,15 @@ import (
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	resourcev1alpha1 "k8s.io/api/resource/v1alpha1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
 	storagev1 "k8s.io/api/storage/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -42,8 +45,8 @@ import (
 	"k8s.io/client-go/informers"
 	coreinformers "k8s.io/client-go/informers/core/v1"
 	clientset "k8s.io/client-go/kubernetes"
+	clientsetscheme "k8s.io/client-go/kubernetes/scheme"
 	restclient "k8s.io/client-go/rest"
-	"k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/events"
 	"k8s.io/klog/v2"
@@ -89,6 +92,8 @@ var (
 		storagev1.SchemeGroupVersion.WithKind("CSINode"):            func() runtime.Object { return &storagev1.CSINode{} },
 		storagev1.SchemeGroupVersion.WithKind("CSIDriver"):          func() runtime.Object { return &storagev1.CSIDriver{} },
 		storagev1.SchemeGroupVersion.WithKind("CSIStorageCapacity"): func() runtime.Object { return &storagev1.CSIStorageCapacity{} },
+		schedulingv1.SchemeGroupVersion.WithKind("PriorityClass"):   func() runtime.Object { return &schedulingv1.PriorityClass{} },
+		policyv1.SchemeGroupVersion.WithKind("PodDisruptionBudget"): func() runtime.Object { return &policyv1.PodDisruptionBudget{} },
 		resourcev1alpha1.SchemeGroupVersion.WithKind("PodScheduling"): func() runtime.Object {
 			if utilfeature.DefaultFeatureGate.Enabled(features.DynamicResourceAllocation) {
 				return &resourcev1alpha1.PodScheduling{}
@@ -104,8 +109,27 @@ var (
 			return nil
 		},
 	}
-	once        sync.Once
-	initObjects []runtime.Object
+	// resources in initResources which are cluster scoped, used to build static rest mapper
+	rootScopedResources = sets.New[schema.GroupVersionKind](
+		corev1.SchemeGroupVersion.WithKind("Namespace"),
+		corev1.SchemeGroupVersion.WithKind("Node"),
+		corev1.SchemeGroupVersion.WithKind("PersistentVolume"),
+		storagev1.SchemeGroupVersion.WithKind("StorageClass"),
+		storagev1.SchemeGroupVersion.WithKind("CSINode"),
+		storagev1.SchemeGroupVersion.WithKind("CSIDriver"),
+		schedulingv1.SchemeGroupVersion.WithKind("PriorityClass"),
+	)
+	// resources in initResources which are not necessary for most of simulations, they are skipped
+	// in tolerant mode if the user is not allowed to list them
+	optionalResources = sets.New[schema.GroupVersionKind](
+		storagev1.SchemeGroupVersion.WithKind("CSIStorageCapacity"),
+		resourcev1alpha1.SchemeGroupVersion.WithKind("ResourceClaim"),
+		policyv1.SchemeGroupVersion.WithKind("PodDisruptionBudget"),
+	)
+	once         sync.Once
+	initObjects  []runtime.Object
+	initWarnings []string
+	initErr      error
 )
 
 type kubeschedulerFramework struct {
@@ -116,8 +140,12 @@ type kubeschedulerFramework struct {
 	// TODO: follow kubernetes master branch code
 	dynInformerFactory dynamicinformer.DynamicSharedInformerFactory
 	restMapper         meta.RESTMapper
-	// real dynamic client to init the world
+	// real dynamic client to init the world, nil if no apiserver is provided
 	dynamicClient *dynamic.DynamicClient
+	// objects the simulation starts from, it could be shared by other frameworks
+	world *World
+	// serves the fake clientset, only stores the objects changed in the world
+	tracker *worldTracker
 
 	// scheduler
 	scheduler                *scheduler.Scheduler
@@ -127,12 +155,31 @@ type kubeschedulerFramework struct {
 	ignorePodsOnExcludesNode bool
 	// deletionTimestamp is not nil and phase is not succeed or failed
 	withTerminatingPods bool
-	outOfTreeRegistry   frameworkruntime.Registry
-	customBind          kubeschedulerconfig.PluginSet
-	customPreBind       kubeschedulerconfig.PluginSet
-	customPostBind      kubeschedulerconfig.PluginSet
-	customEventHandlers []func()
-	postBindHook        func(*corev1.Pod) error
+	// skip optional resources which are forbidden to list when copying the world from cluster
+	tolerant bool
+	scope    worldScope
+	// lower priority pods are preempted if the pod could not be scheduled
+	withPreemption    bool
+	outOfTreeRegistry frameworkruntime.Registry
+	// names of all profiles, pods with other scheduler names are scheduled by the default one
+	schedulerNames       sets.Set[string]
+	defaultSchedulerName string
+	customBind           kubeschedulerconfig.PluginSet
+	customPreBind        kubeschedulerconfig.PluginSet
+	customPostBind       kubeschedulerconfig.PluginSet
+	customEventHandlers  []func()
+	postBindHook         func(*corev1.Pod) error
+
+	// pods preempted but not reported yet
+	victimsMux sync.Mutex
+	victims    []*corev1.Pod
+
+	// records the scheduling decision of each pod if set
+	tracer *Tracer
+	// makes the simulation deterministic if not zero
+	seed int64
+	// max time a pod waits for the other members of its gang, only used by volcano framework
+	gangWaitTime time.Duration
 
 	// for scheduler and informer
 	informerCh  chan struct{}
@@ -163,6 +210,49 @@ func WithOutOfTreeRegistry(registry frameworkruntime.Registry) Option {
 	}
 }
 
+// withPlugin registers an in-tree plugin of the framework, it's applied after WithOutOfTreeRegistry
+// so that the plugin is not overridden by the registry provided by user.
+func withPlugin(name string, factory frameworkruntime.PluginFactory) Option {
+	return func(s *kubeschedulerFramework) {
+		if s.outOfTreeRegistry == nil {
+			s.outOfTreeRegistry = make(frameworkruntime.Registry)
+		}
+		s.outOfTreeRegistry[name] = factory
+	}
+}
+
+// WithTracer records the filter and score results of each pod scheduled by tracer, nothing is recorded if tracer is nil.
+func WithTracer(tracer *Tracer) Option {
+	return func(s *kubeschedulerFramework) {
+		s.tracer = tracer
+	}
+}
+
+// WithWorld shares world with other frameworks, the world is initialized by the first framework calling Initialize
+// and the objects passed to the others are ignored, so they must be initialized with the same objects and options.
+// a framework has its own world by default.
+func WithWorld(world *World) Option {
+	return func(s *kubeschedulerFramework) {
+		s.world = world
+	}
+}
+
+// WithSeed makes the simulation deterministic, the order of nodes and pods is stable, nodes are filtered one by one and
+// ties of node scores are broken by seed. it's disabled if seed is zero.
+func WithSeed(seed int64) Option {
+	return func(s *kubeschedulerFramework) {
+		s.seed = seed
+	}
+}
+
+// WithGangWaitTime sets the max time a pod waits for the other members of its gang, it's only used by volcano framework
+// and volcano.DefaultGangWaitTime is used if it's not positive.
+func WithGangWaitTime(waitTime time.Duration) Option {
+	return func(s *kubeschedulerFramework) {
+		s.gangWaitTime = waitTime
+	}
+}
+
 func WithCustomBind(plugins kubeschedulerconfig.PluginSet) Option {
 	return func(s *kubeschedulerFramework) {
 		s.customBind = plugins
@@ -223,21 +313,53 @@ func WithTerminatingPods(with bool) Option {
 	}
 }
 
+func WithTolerant(tolerant bool) Option {
+	return func(s *kubeschedulerFramework) {
+		s.tolerant = tolerant
+	}
+}
+
+// WithNodeSelector only loads nodes matching selector and pods bound to them into the world.
+func WithNodeSelector(selector labels.Selector) Option {
+	return func(s *kubeschedulerFramework) {
+		s.scope.nodeSelector = selector
+	}
+}
+
+// WithNamespace only loads pending pods in namespace into the world, pods bound to the nodes loaded are always loaded.
+func WithNamespace(namespace string) Option {
+	return func(s *kubeschedulerFramework) {
+		s.scope.namespace = namespace
+	}
+}
+
+// WithPodSelector only loads pending pods matching selector into the world, pods bound to the nodes loaded are always loaded.
+func WithPodSelector(selector labels.Selector) Option {
+	return func(s *kubeschedulerFramework) {
+		s.scope.podSelector = selector
+	}
+}
+
+// WithPreemption enables the default preemption of kube-scheduler, victims are deleted from the world and
+// reported together with the pods preempting them.
+func WithPreemption(with bool) Option {
+	return func(s *kubeschedulerFramework) {
+		s.withPreemption = with
+	}
+}
+
 // NewKubeSchedulerFramework create a generic simulator for ce, cc, ss simulator which is completely independent of apiserver so no need
-// for kubeconfig nor for apiserver url
+// for kubeconfig nor for apiserver url. restConfig is only used to copy the world from a running cluster, it could be nil
+// when the world is initialized by Initialize(objs...).
 func NewKubeSchedulerFramework(kubeSchedulerConfig *schedconfig.CompletedConfig, restConfig *restclient.Config, options ...Option) (pkg.Framework, error) {
-	kubeSchedulerConfig.InformerFactory.InformerFor(&corev1.Pod{}, newPodInformer)
+	return newKubeSchedulerFramework(kubeSchedulerConfig, restConfig, options...)
+}
 
-	dynamicClient := dynamic.NewForConfigOrDie(restConfig)
-	restMapper, err := apiutil.NewDynamicRESTMapper(restConfig)
-	if err != nil {
-		return nil, err
-	}
+func newKubeSchedulerFramework(kubeSchedulerConfig *schedconfig.CompletedConfig, restConfig *restclient.Config, options ...Option) (*kubeschedulerFramework, error) {
+	kubeSchedulerConfig.InformerFactory.InformerFor(&corev1.Pod{}, newPodInformer)
 
 	s := &kubeschedulerFramework{
 		fakeClient:               kubeSchedulerConfig.Client,
-		dynamicClient:            dynamicClient,
-		restMapper:               restMapper,
 		stopCh:                   make(chan struct{}),
 		fakeInformerFactory:      kubeSchedulerConfig.InformerFactory,
 		informerCh:               make(chan struct{}),
@@ -252,10 +374,36 @@ func NewKubeSchedulerFramework(kubeSchedulerConfig *schedconfig.CompletedConfig,
 		option(s)
 	}
 
-	// only for latest k8s version
-	if restConfig != nil {
-		dynClient := dynamic.NewForConfigOrDie(restConfig)
-		s.dynInformerFactory = dynamicinformer.NewFilteredDynamicSharedInformerFactory(dynClient, 0, corev1.NamespaceAll, nil)
+	if s.world == nil {
+		s.world = NewWorld()
+	}
+	s.tracker = newWorldTracker(s.world)
+	if err := useTracker(s.fakeClient, s.tracker); err != nil {
+		return nil, err
+	}
+
+	if s.withPreemption {
+		s.addVictimEventHandler(kubeSchedulerConfig.InformerFactory)
+	}
+
+	if s.seed != 0 {
+		// feasible nodes are found in a random order if nodes are filtered in parallel
+		kubeSchedulerConfig.ComponentConfig.Parallelism = 1
+	}
+
+	if restConfig == nil {
+		s.restMapper = newStaticRESTMapper()
+	} else {
+		dynamicClient := dynamic.NewForConfigOrDie(restConfig)
+		restMapper, err := apiutil.NewDynamicRESTMapper(restConfig)
+		if err != nil {
+			return nil, err
+		}
+		s.dynamicClient = dynamicClient
+		s.restMapper = restMapper
+
+		// only for latest k8s version
+		s.dynInformerFactory = dynamicinformer.NewFilteredDynamicSharedInformerFactory(dynamicClient, 0, corev1.NamespaceAll, nil)
 	}
 
 	scheduler, err := s.createScheduler(kubeSchedulerConfig)
@@ -263,57 +411,84 @@ func NewKubeSchedulerFramework(kubeSchedulerConfig *schedconfig.CompletedConfig,
 		return nil, err
 	}
 
+	if s.seed != 0 {
+		scheduler.SchedulePod = s.tieBreakSchedulePod(scheduler.SchedulePod)
+	}
+	if s.tracer != nil {
+		scheduler.SchedulePod = s.traceSchedulePod(scheduler.SchedulePod)
+	}
 	s.scheduler = scheduler
 
 	return s, nil
 }
 
+// GetPodsByNode returns the pods bound to node from the index of the world, it's much cheaper than dumping the
+// scheduler cache for large clusters. the pods returned must not be modified.
 func (s *kubeschedulerFramework) GetPodsByNode(nodeName string) ([]*corev1.Pod, error) {
-	dump := s.scheduler.Cache.Dump()
-	var res []*corev1.Pod
-	if dump != nil && dump.Nodes[nodeName] != nil {
-		podInfos := dump.Nodes[nodeName].Pods
-		for i := range podInfos {
-			if podInfos[i].Pod != nil {
-				res = append(res, podInfos[i].Pod)
-			}
-		}
+	pods := s.tracker.podsOnNode(nodeName)
+	if len(pods) == 0 {
+		return nil, fmt.Errorf("no pods on node %s", nodeName)
 	}
 
-	if res == nil {
-		return nil, errors.New("cannot get pods on the node because dump is nil")
-	}
-	return res, nil
+	return pods, nil
 }
 
 // InitTheWorld use objs outside or default init resources to initialize the scheduler
-// the objs outside must be typed object.
+// the objs outside must be typed object. the world is only initialized once if it's shared.
 func (s *kubeschedulerFramework) Initialize(objs ...runtime.Object) error {
+	err := s.world.init(func() error {
+		return s.initWorld(objs)
+	})
+	s.status.Warnings = append(s.status.Warnings, s.world.warnings...)
+
+	return err
+}
+
+func (s *kubeschedulerFramework) initWorld(objs []runtime.Object) error {
 	if len(objs) == 0 {
+		if s.dynamicClient == nil {
+			return errors.New("no objects to initialize the world and no cluster to copy from")
+		}
+
 		// black magic
 		klog.V(2).InfoS("Init the world form running cluster")
-		initObjects := getInitObjects(s.restMapper, s.dynamicClient)
+		initObjects, warnings, err := getInitObjects(s.restMapper, s.dynamicClient, s.tolerant, &s.scope)
+		if err != nil {
+			return err
+		}
+		s.world.warnings = append(s.world.warnings, warnings...)
 		for _, unstructuredObj := range initObjects {
 			obj := initResources[unstructuredObj.GetObjectKind().GroupVersionKind()]()
 			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(unstructuredObj.(*unstructured.Unstructured).UnstructuredContent(), obj); err != nil {
 				return err
 			}
-			if needAdd, obj := s.preAdd(obj); needAdd {
-				if err := s.fakeClient.(testing.FakeClient).Tracker().Add(obj); err != nil {
-					return err
-				}
-			}
+			objs = append(objs, obj)
 		}
 	} else {
 		klog.V(2).InfoS("Init the world form snapshot")
+		knownObjs := make([]runtime.Object, 0, len(objs))
 		for _, obj := range objs {
+			if !s.isKnownObject(obj) {
+				continue
+			}
 			if _, ok := obj.(runtime.Unstructured); ok {
 				return errors.New("type of objs used to init the world must not be unstructured")
 			}
-			if needAdd, obj := s.preAdd(obj); needAdd {
-				if err := s.fakeClient.(testing.FakeClient).Tracker().Add(obj); err != nil {
-					return err
-				}
+			knownObjs = append(knownObjs, obj)
+		}
+		objs = knownObjs
+	}
+
+	// an empty world is never expected, e.g. the snapshot is empty or nothing matches the scope
+	objs = s.scope.filter(objs)
+	if len(objs) == 0 {
+		return errors.New("no objects to initialize the world in the scope")
+	}
+
+	for _, obj := range objs {
+		if needAdd, obj := s.preAdd(obj); needAdd {
+			if err := s.world.add(obj); err != nil {
+				return err
 			}
 		}
 	}
@@ -323,6 +498,56 @@ func (s *kubeschedulerFramework) Initialize(objs ...runtime.Object) error {
 
 func (s *kubeschedulerFramework) UpdateEstimationPods(pod ...*corev1.Pod) {
 	s.status.PodsForEstimation = append(s.status.PodsForEstimation, pod...)
+
+	if !s.withPreemption {
+		return
+	}
+
+	// victims are preempted on the node the preemptor is nominated to, which may differ from the node it's bound to
+	nominatedNodes := make([]string, len(pod))
+	for i, p := range pod {
+		nominatedNodes[i] = s.nominatedNodeName(p)
+	}
+
+	var pending []*corev1.Pod
+	for _, victim := range s.takeVictims() {
+		preemptor := ""
+		for i, p := range pod {
+			if len(nominatedNodes[i]) > 0 && nominatedNodes[i] == victim.Spec.NodeName {
+				preemptor = p.Namespace + "/" + p.Name
+				break
+			}
+		}
+		// the preemptor may be bound later
+		if len(preemptor) == 0 {
+			pending = append(pending, victim)
+			continue
+		}
+
+		if s.status.Victims == nil {
+			s.status.Victims = make(map[string][]*corev1.Pod)
+		}
+		s.status.Victims[preemptor] = append(s.status.Victims[preemptor], victim)
+	}
+
+	s.victimsMux.Lock()
+	s.victims = append(s.victims, pending...)
+	s.victimsMux.Unlock()
+}
+
+// nominatedNodeName returns the node pod was nominated to by preemption, the bound pod may be copied before
+// the nomination was updated so the pod in the world is looked up too.
+func (s *kubeschedulerFramework) nominatedNodeName(pod *corev1.Pod) string {
+	if len(pod.Status.NominatedNodeName) > 0 {
+		return pod.Status.NominatedNodeName
+	}
+
+	latest, err := s.fakeClient.CoreV1().Pods(pod.Namespace).Get(context.TODO(), pod.Name, metav1.GetOptions{ResourceVersion: "0"})
+	if err != nil {
+		return ""
+	}
+
+	return latest.Status.NominatedNodeName
 }
 
 func (s *kubeschedulerFramework) UpdateNodesToScaleDown(nodeName string) {
@@ -355,6 +580,11 @@ func (s *kubeschedulerFramework) Stop(reason string) error {
 
 	s.status.StopReason = reason
 
+	// the preemptors of victims left are not bound before the simulation stops
+	if s.withPreemption {
+		s.status.UnattributedVictims = append(s.status.UnattributedVictims, s.takeVictims()...)
+	}
+
 	if len(s.saveTo) > 0 {
 		file, err := os.OpenFile(s.saveTo, os.O_CREATE|os.O_RDWR, 0755)
 		if err != nil {
@@ -385,11 +615,15 @@ func (s *kubeschedulerFramework) Stop(reason string) error {
 }
 
 func (s *kubeschedulerFramework) CreatePod(pod *corev1.Pod) error {
+	s.resolveSchedulerName(pod)
+	s.resolvePriority(pod)
 	_, err := s.fakeClient.CoreV1().Pods(pod.Namespace).Create(context.TODO(), pod, metav1.CreateOptions{})
 	return err
 }
 
-func (s *kubeschedulerFramework) Run(init func() error) error {
+// Run starts the scheduler and blocks until the simulation is stopped or ctx is done, the simulation is
+// stopped with a Timeout or Interrupted reason in the latter case so that a partial result could be reported.
+func (s *kubeschedulerFramework) Run(ctx context.Context, init func() error) error {
 	// wait for all informer cache synced
 	s.fakeInformerFactory.Start(s.informerCh)
 	if s.dynInformerFactory != nil {
@@ -397,9 +631,23 @@ func (s *kubeschedulerFramework) Run(init func() error) error {
 	}
 	start := time.Now()
 
-	s.fakeInformerFactory.WaitForCacheSync(s.informerCh)
+	// waiting is given up once ctx is done, so that the startup could be interrupted too
+	syncCh := make(chan struct{})
+	synced := make(chan struct{})
+	go func() {
+		defer close(syncCh)
+		select {
+		case <-ctx.Done():
+		case <-synced:
+		}
+	}()
+	s.fakeInformerFactory.WaitForCacheSync(syncCh)
 	if s.dynInformerFactory != nil {
-		s.dynInformerFactory.WaitForCacheSync(s.informerCh)
+		s.dynInformerFactory.WaitForCacheSync(syncCh)
+	}
+	close(synced)
+	if ctx.Err() != nil {
+		return s.stopByContext(ctx)
 	}
 
 	klog.V(4).InfoS("wait sync", "cost", time.Since(start).Milliseconds())
@@ -411,13 +659,29 @@ func (s *kubeschedulerFramework) Run(init func() error) error {
 		}
 	}
 
-	go s.scheduler.Run(context.TODO())
+	// ctx is read by the goroutine waiting for the cache sync, so it's not reassigned
+	schedulerCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go s.scheduler.Run(schedulerCtx)
 
-	<-s.stopCh
+	select {
+	case <-s.stopCh:
+	case <-ctx.Done():
+		return s.stopByContext(ctx)
+	}
 
 	return nil
 }
 
+// stopByContext stops the simulation with a Timeout or Interrupted reason once ctx is done.
+func (s *kubeschedulerFramework) stopByContext(ctx context.Context) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return s.Stop(pkg.StopTypeTimeout + ": simulation did not finish before the deadline")
+	}
+
+	return s.Stop(pkg.StopTypeInterrupted + ": simulation was interrupted")
+}
+
 func (s *kubeschedulerFramework) createScheduler(cc *schedconfig.CompletedConfig) (*scheduler.Scheduler, error) {
 	// custom event handlers
 	for _, handler := range s.customEventHandlers {
@@ -434,21 +698,40 @@ func (s *kubeschedulerFramework) createScheduler(cc *schedconfig.CompletedConfig
 	if err != nil {
 		return nil, err
 	}
+	if s.withPreemption && s.seed != 0 {
+		if err := s.outOfTreeRegistry.Register(seededPreemptionName, newSeededPreemption(s.seed)); err != nil {
+			return nil, err
+		}
+	}
 
-	cc.ComponentConfig.Profiles[0].Plugins.PreBind.Enabled = append(cc.ComponentConfig.Profiles[0].Plugins.PreBind.Enabled, kubeschedulerconfig.Plugin{Name: generic.Name})
-	cc.ComponentConfig.Profiles[0].Plugins.PreBind.Disabled = append(cc.ComponentConfig.Profiles[0].Plugins.PreBind.Disabled, kubeschedulerconfig.Plugin{Name: volumebinding.Name})
-	cc.ComponentConfig.Profiles[0].Plugins.Bind.Enabled = append(cc.ComponentConfig.Profiles[0].Plugins.Bind.Enabled, kubeschedulerconfig.Plugin{Name: generic.Name})
-	cc.ComponentConfig.Profiles[0].Plugins.Bind.Disabled = append(cc.ComponentConfig.Profiles[0].Plugins.Bind.Disabled, kubeschedulerconfig.Plugin{Name: defaultbinder.Name})
-	cc.ComponentConfig.Profiles[0].Plugins.PostBind.Enabled = append(cc.ComponentConfig.Profiles[0].Plugins.PostBind.Enabled, kubeschedulerconfig.Plugin{Name: generic.Name})
-	cc.ComponentConfig.Profiles[0].Plugins.PostFilter.Disabled = append(cc.ComponentConfig.Profiles[0].Plugins.PostFilter.Disabled, kubeschedulerconfig.Plugin{Name: defaultpreemption.Name})
+	// inject generic plugin into all profiles so that each pod is scheduled by the profile it really uses
+	s.schedulerNames = sets.New[string]()
+	for i := range cc.ComponentConfig.Profiles {
+		profile := &cc.ComponentConfig.Profiles[i]
+		s.schedulerNames.Insert(profile.SchedulerName)
+
+		plugins := profile.Plugins
+		// volumes are bound by generic plugin since there is no pv controller in the world
+		plugins.PreBind.Enabled = append(plugins.PreBind.Enabled, kubeschedulerconfig.Plugin{Name: generic.Name})
+		plugins.PreBind.Disabled = append(plugins.PreBind.Disabled, kubeschedulerconfig.Plugin{Name: volumebinding.Name})
+		plugins.Bind.Enabled = append(plugins.Bind.Enabled, kubeschedulerconfig.Plugin{Name: generic.Name})
+		plugins.Bind.Disabled = append(plugins.Bind.Disabled, kubeschedulerconfig.Plugin{Name: defaultbinder.Name})
+		plugins.PostBind.Enabled = append(plugins.PostBind.Enabled, kubeschedulerconfig.Plugin{Name: generic.Name})
+		if !s.withPreemption {
+			plugins.PostFilter.Disabled = append(plugins.PostFilter.Disabled, kubeschedulerconfig.Plugin{Name: defaultpreemption.Name})
+		} else if s.seed != 0 {
+			useSeededPreemption(profile)
+		}
 
-	// custom bind plugin
-	cc.ComponentConfig.Profiles[0].Plugins.PreBind.Enabled = append(cc.ComponentConfig.Profiles[0].Plugins.PreBind.Enabled, s.customPreBind.Enabled...)
-	cc.ComponentConfig.Profiles[0].Plugins.PreBind.Disabled = append(cc.ComponentConfig.Profiles[0].Plugins.PreBind.Disabled, s.customPreBind.Disabled...)
-	cc.ComponentConfig.Profiles[0].Plugins.Bind.Enabled = append(cc.ComponentConfig.Profiles[0].Plugins.Bind.Enabled, s.customBind.Enabled...)
-	cc.ComponentConfig.Profiles[0].Plugins.Bind.Disabled = append(cc.ComponentConfig.Profiles[0].Plugins.Bind.Disabled, s.customBind.Disabled...)
-	cc.ComponentConfig.Profiles[0].Plugins.PostBind.Enabled = append(cc.ComponentConfig.Profiles[0].Plugins.PostBind.Enabled, s.customPostBind.Enabled...)
-	cc.ComponentConfig.Profiles[0].Plugins.PostBind.Disabled = append(cc.ComponentConfig.Profiles[0].Plugins.PostBind.Disabled, s.customPostBind.Disabled...)
+		// custom bind plugin
+		plugins.PreBind.Enabled = append(plugins.PreBind.Enabled, s.customPreBind.Enabled...)
+		plugins.PreBind.Disabled = append(plugins.PreBind.Disabled, s.customPreBind.Disabled...)
+		plugins.Bind.Enabled = append(plugins.Bind.Enabled, s.customBind.Enabled...)
+		plugins.Bind.Disabled = append(plugins.Bind.Disabled, s.customBind.Disabled...)
+		plugins.PostBind.Enabled = append(plugins.PostBind.Enabled, s.customPostBind.Enabled...)
+		plugins.PostBind.Disabled = append(plugins.PostBind.Disabled, s.customPostBind.Disabled...)
+	}
+	s.defaultSchedulerName = cc.ComponentConfig.Profiles[0].SchedulerName
 
 	// create the scheduler.
 	return scheduler.New(
@@ -469,6 +752,28 @@ func (s *kubeschedulerFramework) createScheduler(cc *schedconfig.CompletedConfig
 	)
 }
 
+// isKnownObject returns true if obj is one of the resources the scheduler cares about.
+func (s *kubeschedulerFramework) isKnownObject(obj runtime.Object) bool {
+	gvks := []schema.GroupVersionKind{obj.GetObjectKind().GroupVersionKind()}
+	if _, ok := obj.(runtime.Unstructured); !ok {
+		var err error
+		gvks, _, err = clientsetscheme.Scheme.ObjectKinds(obj)
+		if err != nil {
+			klog.V(2).InfoS("Skip object of unknown type", "type", fmt.Sprintf("%T", obj), "err", err)
+			return false
+		}
+	}
+
+	for _, gvk := range gvks {
+		if _, err := s.restMapper.RESTMapping(gvk.GroupKind(), gvk.Version); err == nil {
+			return true
+		}
+	}
+	klog.V(2).InfoS("Skip object not used by scheduler", "gvk", gvks[0].String())
+
+	return false
+}
+
 func (s *kubeschedulerFramework) preAdd(obj runtime.Object) (bool, runtime.Object) {
 	// filter exclude nodes and pods and update pod, node spec and status property
 	if pod, ok := obj.(*corev1.Pod); ok {
@@ -489,16 +794,26 @@ func (s *kubeschedulerFramework) preAdd(obj runtime.Object) (bool, runtime.Objec
 			return false, nil
 		}
 
+		// all pods are rescheduled by ss, so the scheduler name is only resolved here
 		if !s.withScheduledPods && !utils.IsDaemonsetPod(pod.OwnerReferences) {
 			pod := utils.InitPod(pod)
 			pod.Status.Phase = corev1.PodPending
+			s.resolveSchedulerName(pod)
 
 			return true, pod
 		}
+
+		// pending pods of the world must not take the capacity simulated, pods of schedulers which are not simulated
+		// are kept unschedulable and the others are left out since they would be scheduled by their profiles
+		if len(pod.Spec.NodeName) == 0 && s.schedulerNames.Has(pod.Spec.SchedulerName) {
+			klog.V(4).InfoS("Skip pending pod", "pod", klog.KObj(pod), "schedulerName", pod.Spec.SchedulerName)
+			return false, nil
+		}
 	} else if node, ok := obj.(*corev1.Node); ok && s.excludeNodes != nil {
 		if _, ok := s.excludeNodes[node.Name]; ok {
 			return false, nil
 		} else if !s.withNodeImages {
+			node := node.DeepCopy()
 			node.Status.Images = nil
 
 			return true, node
@@ -508,6 +823,18 @@ func (s *kubeschedulerFramework) preAdd(obj runtime.Object) (bool, runtime.Objec
 	return true, obj
 }
 
+// resolveSchedulerName makes sure the pod is scheduled by one of the profiles, pods using a scheduler which
+// is not simulated, e.g. scheduled by another scheduler in the cluster, fall back to the first profile. it's only
+// applied to the pods simulated, pending pods of the world are never rewritten except by ss.
+func (s *kubeschedulerFramework) resolveSchedulerName(pod *corev1.Pod) {
+	if s.schedulerNames.Has(pod.Spec.SchedulerName) {
+		return
+	}
+
+	klog.V(4).InfoS("Scheduler of pod is not simulated, use the default one", "pod", klog.KObj(pod), "schedulerName", pod.Spec.SchedulerName, "default", s.defaultSchedulerName)
+	pod.Spec.SchedulerName = s.defaultSchedulerName
+}
+
 func newPodInformer(cs clientset.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
 	selector := fmt.Sprintf("status.phase!=%v,status.phase!=%v", corev1.PodSucceeded, corev1.PodFailed)
 	tweakListOptions := func(options *metav1.ListOptions) {
@@ -522,51 +849,123 @@ func getRecorderFactory(cc *schedconfig.CompletedConfig) profile.RecorderFactory
 	}
 }
 
-// getInitObjects return all objects need to add to scheduler.
+// newStaticRESTMapper returns a rest mapper only knows resources in initResources, it's used when there is no apiserver.
+func newStaticRESTMapper() meta.RESTMapper {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	for gvk := range initResources {
+		if rootScopedResources.Has(gvk) {
+			mapper.Add(gvk, meta.RESTScopeRoot)
+		} else {
+			mapper.Add(gvk, meta.RESTScopeNamespace)
+		}
+	}
+
+	return mapper
+}
+
+// GetInitObjects return all objects need to add to scheduler from the cluster specified by restConfig, the cluster
+// is listed each time it's called. the objects returned are unstructured. only WithTolerant and the options scoping
+// the world take effect.
+func GetInitObjects(restConfig *restclient.Config, options ...Option) ([]runtime.Object, []string, error) {
+	s := &kubeschedulerFramework{}
+	for _, option := range options {
+		option(s)
+	}
+
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	restMapper, err := apiutil.NewDynamicRESTMapper(restConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	objs, warnings, err := listInitObjects(restMapper, dynamicClient, s.tolerant, &s.scope)
+	if err != nil {
+		return nil, nil, err
+	}
+	objs = s.scope.filter(objs)
+
+	volcanoObjs, volcanoWarnings, err := listVolcanoObjects(restMapper, dynamicClient, s.tolerant)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return append(objs, volcanoObjs...), append(warnings, volcanoWarnings...), nil
+}
+
+// getInitObjects return all objects need to add to scheduler and warnings of the optional resources skipped.
 // it's pkg scope for multi scheduler to avoid calling too much times of real kube-apiserver
-func getInitObjects(restMapper meta.RESTMapper, dynClient dynamic.Interface) []runtime.Object {
+func getInitObjects(restMapper meta.RESTMapper, dynClient dynamic.Interface, tolerant bool, scope *worldScope) ([]runtime.Object, []string, error) {
 	once.Do(func() {
-		// each item is UnstructuredList
-		for gvk := range initResources {
-			restMapping, err := restMapper.RESTMapping(gvk.GroupKind(), gvk.Version)
-			if err != nil && !meta.IsNoMatchError(err) {
-				fmt.Printf("unable to get rest mapping for %s, error: %s", gvk.String(), err.Error())
-				os.Exit(1)
-			}
+		initObjects, initWarnings, initErr = listInitObjects(restMapper, dynClient, tolerant, scope)
+	})
 
-			if restMapping != nil {
-				var (
-					list *unstructured.UnstructuredList
-					err  error
-				)
-				if restMapping.Scope.Name() == meta.RESTScopeNameRoot {
-					list, err = dynClient.Resource(restMapping.Resource).List(context.TODO(), metav1.ListOptions{ResourceVersion: "0"})
-					if err != nil && !apierrors.IsNotFound(err) {
-						fmt.Printf("unable to list %s, error: %s", gvk.String(), err.Error())
-						os.Exit(1)
-					}
-				} else {
-					if restMapping.Resource.Resource == "pods" {
-						list, err = dynClient.Resource(restMapping.Resource).Namespace(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{
-							ResourceVersion: "0",
-							FieldSelector:   fmt.Sprintf("status.phase!=%v,status.phase!=%v", corev1.PodSucceeded, corev1.PodFailed),
-						})
-					} else {
-						list, err = dynClient.Resource(restMapping.Resource).Namespace(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{ResourceVersion: "0"})
-					}
-					if err != nil && !apierrors.IsNotFound(err) {
-						fmt.Printf("unable to list %s, error: %s", gvk.String(), err.Error())
-						os.Exit(1)
-					}
-				}
+	return initObjects, initWarnings, initErr
+}
+
+// listInitObjects lists all objects need to add to scheduler from the cluster each time it's called.
+func listInitObjects(restMapper meta.RESTMapper, dynClient dynamic.Interface, tolerant bool, scope *worldScope) ([]runtime.Object, []string, error) {
+	var (
+		objs     []runtime.Object
+		warnings []string
+	)
 
-				_ = list.EachListItem(func(object runtime.Object) error {
-					initObjects = append(initObjects, object)
-					return nil
-				})
+	// each item is UnstructuredList
+	for gvk := range initResources {
+		restMapping, err := restMapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil && !meta.IsNoMatchError(err) {
+			return nil, nil, &InitError{GVK: gvk, Op: "get rest mapping for", Err: err}
+		}
+
+		if restMapping != nil {
+			listOptions := metav1.ListOptions{ResourceVersion: "0"}
+			if restMapping.Resource.Resource == "pods" {
+				listOptions.FieldSelector = fmt.Sprintf("status.phase!=%v,status.phase!=%v", corev1.PodSucceeded, corev1.PodFailed)
+			}
+			listOptions = scope.listOptions(gvk, listOptions)
+
+			items, err := listItems(dynClient, restMapping, metav1.NamespaceAll, listOptions)
+			if err != nil {
+				if tolerant && optionalResources.Has(gvk) && apierrors.IsForbidden(err) {
+					klog.V(2).InfoS("Skip optional resource", "gvk", gvk.String(), "err", err)
+					warnings = append(warnings, fmt.Sprintf("%s is skipped since it is forbidden to list", gvk.Kind))
+					continue
+				}
+				return nil, nil, &InitError{GVK: gvk, Op: "list", Err: err}
 			}
+			objs = append(objs, items...)
 		}
+	}
+
+	return objs, warnings, nil
+}
+
+// listItems lists the objects of restMapping in namespace, nothing is returned if the resource is not found.
+func listItems(dynClient dynamic.Interface, restMapping *meta.RESTMapping, namespace string, listOptions metav1.ListOptions) ([]runtime.Object, error) {
+	var (
+		list *unstructured.UnstructuredList
+		err  error
+	)
+	if restMapping.Scope.Name() == meta.RESTScopeNameRoot {
+		list, err = dynClient.Resource(restMapping.Resource).List(context.TODO(), listOptions)
+	} else {
+		list, err = dynClient.Resource(restMapping.Resource).Namespace(namespace).List(context.TODO(), listOptions)
+	}
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var objs []runtime.Object
+	_ = list.EachListItem(func(object runtime.Object) error {
+		objs = append(objs, object)
+		return nil
 	})
 
-	return initObjects
+	return objs, nil
 }
@@ -0,0 +1,78 @@
+package framework
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"github.com/k-cloud-labs/kluster-capacity/pkg"
+)
+
+// addVictimEventHandler records the pods deleted by preemption, pods provisioned by simulator are never victims
+// since they are only deleted by simulator itself.
+func (s *kubeschedulerFramework) addVictimEventHandler(informerFactory informers.SharedInformerFactory) {
+	_, _ = informerFactory.Core().V1().Pods().Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: func(obj interface{}) bool {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			pod, ok := obj.(*corev1.Pod)
+			return ok && !metav1.HasAnnotation(pod.ObjectMeta, pkg.PodProvisioner)
+		},
+		Handler: cache.ResourceEventHandlerFuncs{
+			DeleteFunc: func(obj interface{}) {
+				if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+					obj = tombstone.Obj
+				}
+				pod := obj.(*corev1.Pod)
+				klog.V(2).InfoS("Pod is preempted", "pod", klog.KObj(pod), "node", pod.Spec.NodeName)
+
+				s.victimsMux.Lock()
+				defer s.victimsMux.Unlock()
+				s.victims = append(s.victims, pod)
+			},
+		},
+	})
+}
+
+// takeVictims returns the pods preempted since last call.
+func (s *kubeschedulerFramework) takeVictims() []*corev1.Pod {
+	s.victimsMux.Lock()
+	defer s.victimsMux.Unlock()
+
+	victims := s.victims
+	s.victims = nil
+	return victims
+}
+
+// resolvePriority sets the priority of pod from its PriorityClass like the Priority admission plugin does,
+// it's needed by pods created from template which have not been admitted by apiserver.
+func (s *kubeschedulerFramework) resolvePriority(pod *corev1.Pod) {
+	if pod.Spec.Priority != nil {
+		return
+	}
+
+	priorityClasses, err := s.fakeClient.SchedulingV1().PriorityClasses().List(context.TODO(), metav1.ListOptions{ResourceVersion: "0"})
+	if err != nil {
+		klog.ErrorS(err, "Failed to list priority classes")
+		return
+	}
+
+	for i := range priorityClasses.Items {
+		pc := &priorityClasses.Items[i]
+		if (len(pod.Spec.PriorityClassName) == 0 && pc.GlobalDefault) || pc.Name == pod.Spec.PriorityClassName {
+			priority := pc.Value
+			pod.Spec.Priority = &priority
+			pod.Spec.PriorityClassName = pc.Name
+			if pod.Spec.PreemptionPolicy == nil && pc.PreemptionPolicy != nil {
+				policy := *pc.PreemptionPolicy
+				pod.Spec.PreemptionPolicy = &policy
+			}
+			return
+		}
+	}
+}
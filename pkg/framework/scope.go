@@ -0,0 +1,127 @@
+package framework
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+var (
+	nodeGVK    = corev1.SchemeGroupVersion.WithKind("Node")
+	podGVK     = corev1.SchemeGroupVersion.WithKind("Pod")
+	csiNodeGVK = storagev1.SchemeGroupVersion.WithKind("CSINode")
+)
+
+// worldScope limits the nodes and pods loaded into the world, so that only a part of a large cluster,
+// e.g. a node pool, is simulated.
+type worldScope struct {
+	// only nodes matching nodeSelector and pods bound to them are loaded
+	nodeSelector labels.Selector
+	// only pending pods in namespace and matching podSelector are loaded, pods bound to the selected nodes
+	// are always loaded since they take resources of the nodes
+	namespace   string
+	podSelector labels.Selector
+}
+
+func (w *worldScope) empty() bool {
+	return w.nodeSelector == nil && len(w.namespace) == 0 && w.podSelector == nil
+}
+
+// listOptions returns the options used to list gvk from apiserver, so that nodes out of the scope are filtered
+// by apiserver. Pods are filtered by filter only since pods in any namespace could be bound to the selected nodes.
+func (w *worldScope) listOptions(gvk schema.GroupVersionKind, options metav1.ListOptions) metav1.ListOptions {
+	if gvk == nodeGVK && w.nodeSelector != nil {
+		options.LabelSelector = w.nodeSelector.String()
+	}
+
+	return options
+}
+
+// filter drops nodes not matching the node selector together with their CSINodes, pods bound to the dropped nodes,
+// and pending pods not matching namespace and pod selector. objs could be either typed or unstructured.
+func (w *worldScope) filter(objs []runtime.Object) []runtime.Object {
+	if w.empty() {
+		return objs
+	}
+
+	// nodes are selected first since pods depend on them
+	var nodes sets.Set[string]
+	if w.nodeSelector != nil {
+		nodes = sets.New[string]()
+		for _, obj := range objs {
+			if gvkOf(obj) != nodeGVK {
+				continue
+			}
+			if accessor, err := meta.Accessor(obj); err == nil && w.nodeSelector.Matches(labels.Set(accessor.GetLabels())) {
+				nodes.Insert(accessor.GetName())
+			}
+		}
+	}
+
+	result := make([]runtime.Object, 0, len(objs))
+	for _, obj := range objs {
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			result = append(result, obj)
+			continue
+		}
+
+		switch gvkOf(obj) {
+		case nodeGVK, csiNodeGVK:
+			if nodes != nil && !nodes.Has(accessor.GetName()) {
+				continue
+			}
+		case podGVK:
+			nodeName := nodeNameOf(obj)
+			// pending pods are dropped too since it's unknown which nodes they are going to
+			if nodes != nil && !nodes.Has(nodeName) {
+				continue
+			}
+			// pods bound to the selected nodes are always kept since they take resources of the nodes
+			if len(nodeName) > 0 {
+				break
+			}
+			if len(w.namespace) > 0 && accessor.GetNamespace() != w.namespace {
+				continue
+			}
+			if w.podSelector != nil && !w.podSelector.Matches(labels.Set(accessor.GetLabels())) {
+				continue
+			}
+		}
+
+		result = append(result, obj)
+	}
+
+	return result
+}
+
+func gvkOf(obj runtime.Object) schema.GroupVersionKind {
+	switch obj.(type) {
+	case *corev1.Node:
+		return nodeGVK
+	case *corev1.Pod:
+		return podGVK
+	case *storagev1.CSINode:
+		return csiNodeGVK
+	default:
+		return obj.GetObjectKind().GroupVersionKind()
+	}
+}
+
+func nodeNameOf(obj runtime.Object) string {
+	if pod, ok := obj.(*corev1.Pod); ok {
+		return pod.Spec.NodeName
+	}
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		nodeName, _, _ := unstructured.NestedString(u.Object, "spec", "nodeName")
+		return nodeName
+	}
+
+	return ""
+}
@@ -0,0 +1,224 @@
+package framework
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	corev1helpers "k8s.io/component-helpers/scheduling/corev1"
+	extenderv1 "k8s.io/kube-scheduler/extender/v1"
+	"k8s.io/kubernetes/pkg/scheduler"
+	kubeschedulerconfig "k8s.io/kubernetes/pkg/scheduler/apis/config"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/defaultpreemption"
+	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/feature"
+	"k8s.io/kubernetes/pkg/scheduler/framework/preemption"
+	frameworkruntime "k8s.io/kubernetes/pkg/scheduler/framework/runtime"
+)
+
+// tieBreakSchedulePod wraps schedulePod to break the ties of node scores by seed.
+func (s *kubeschedulerFramework) tieBreakSchedulePod(schedulePod schedulePodFunc) schedulePodFunc {
+	return func(ctx context.Context, fwk framework.Framework, state *framework.CycleState, pod *corev1.Pod) (scheduler.ScheduleResult, error) {
+		return schedulePod(ctx, &tieBreakFramework{Framework: fwk, seed: s.seed}, state, pod)
+	}
+}
+
+// tieBreakFramework breaks the ties of node scores by a hash of the seed, the pod and the node, so that the node
+// selected among the nodes with the same highest score is stable instead of random.
+type tieBreakFramework struct {
+	framework.Framework
+	seed int64
+}
+
+// HasScorePlugins is always true so that RunScorePlugins breaks the ties even if no score plugin is enabled, otherwise
+// every node is scored 1 without calling it and the node is selected randomly.
+func (f *tieBreakFramework) HasScorePlugins() bool {
+	return true
+}
+
+func (f *tieBreakFramework) RunScorePlugins(ctx context.Context, state *framework.CycleState, pod *corev1.Pod, nodes []*corev1.Node) ([]framework.NodePluginScores, *framework.Status) {
+	nodeScores, status := f.Framework.RunScorePlugins(ctx, state, pod, nodes)
+	// scores of extenders are added to the total scores later, they could not be scaled
+	if !status.IsSuccess() || len(f.Extenders()) > 0 {
+		return nodeScores, status
+	}
+
+	hashes := make([]uint64, len(nodeScores))
+	indexes := make([]int, len(nodeScores))
+	for i := range nodeScores {
+		hashes[i] = seededHash(f.seed, pod.Namespace+"/"+pod.Name+"/"+nodeScores[i].Name)
+		indexes[i] = i
+	}
+	sort.Slice(indexes, func(i, j int) bool {
+		if hashes[indexes[i]] != hashes[indexes[j]] {
+			return hashes[indexes[i]] < hashes[indexes[j]]
+		}
+		return nodeScores[indexes[i]].Name < nodeScores[indexes[j]].Name
+	})
+
+	// total scores are scaled so that the rank only makes a difference between nodes with the same score
+	for rank, i := range indexes {
+		nodeScores[i].TotalScore = nodeScores[i].TotalScore*int64(len(nodeScores)) + int64(rank)
+	}
+
+	return nodeScores, status
+}
+
+func seededHash(seed int64, key string) uint64 {
+	h := fnv.New64a()
+	_ = binary.Write(h, binary.LittleEndian, seed)
+	_, _ = h.Write([]byte(key))
+	return h.Sum64()
+}
+
+const seededPreemptionName = "SeededPreemption"
+
+// seededPreemption is DefaultPreemption except that candidates are always searched in all nodes instead of from
+// a random one and the ties left by the rules of DefaultPreemption are broken by seed, so that the node to preempt is stable.
+type seededPreemption struct {
+	*defaultpreemption.DefaultPreemption
+	fh   framework.Handle
+	seed int64
+}
+
+func newSeededPreemption(seed int64) frameworkruntime.PluginFactory {
+	return func(configuration runtime.Object, fh framework.Handle) (framework.Plugin, error) {
+		pl, err := defaultpreemption.New(configuration, fh, feature.Features{})
+		if err != nil {
+			return nil, err
+		}
+
+		return &seededPreemption{
+			DefaultPreemption: pl.(*defaultpreemption.DefaultPreemption),
+			fh:                fh,
+			seed:              seed,
+		}, nil
+	}
+}
+
+func (pl *seededPreemption) Name() string {
+	return seededPreemptionName
+}
+
+// PostFilter is the same as DefaultPreemption, except that the evaluator calls back GetOffsetAndNumCandidates
+// and CandidatesToVictimsMap of pl.
+func (pl *seededPreemption) PostFilter(ctx context.Context, state *framework.CycleState, pod *corev1.Pod, m framework.NodeToStatusMap) (*framework.PostFilterResult, *framework.Status) {
+	pe := preemption.Evaluator{
+		PluginName: defaultpreemption.Name,
+		Handler:    pl.fh,
+		PodLister:  pl.fh.SharedInformerFactory().Core().V1().Pods().Lister(),
+		PdbLister:  pl.fh.SharedInformerFactory().Policy().V1().PodDisruptionBudgets().Lister(),
+		State:      state,
+		Interface:  pl,
+	}
+
+	result, status := pe.Preempt(ctx, pod, m)
+	if status.Message() != "" {
+		return result, framework.NewStatus(status.Code(), "preemption: "+status.Message())
+	}
+	return result, status
+}
+
+// GetOffsetAndNumCandidates searches all nodes from the first one, since the dry run is parallel, the candidates found
+// first would depend on the timing if it stopped at a number of candidates.
+func (pl *seededPreemption) GetOffsetAndNumCandidates(numNodes int32) (int32, int32) {
+	return 0, numNodes
+}
+
+// CandidatesToVictimsMap only keeps one of the candidates which the rules of DefaultPreemption could not tell apart,
+// i.e. with the same number of PDB violations, highest and sum of priorities, number of victims and earliest start time
+// of the victims with the highest priority. the one with the smallest hash of seed and node is kept, so the candidate
+// picked no longer depends on the random order of the map. the victims are untouched and the map is kept as is if
+// there are extenders, which also use it.
+func (pl *seededPreemption) CandidatesToVictimsMap(candidates []preemption.Candidate) map[string]*extenderv1.Victims {
+	victimsMap := pl.DefaultPreemption.CandidatesToVictimsMap(candidates)
+	if len(victimsMap) <= 1 || len(pl.fh.Extenders()) > 0 {
+		return victimsMap
+	}
+
+	kept := make(map[string]string, len(victimsMap))
+	for node, victims := range victimsMap {
+		key := tieKey(victims)
+		if other, ok := kept[key]; !ok || seededLess(pl.seed, node, other) {
+			kept[key] = node
+		}
+	}
+
+	result := make(map[string]*extenderv1.Victims, len(kept))
+	for _, node := range kept {
+		result[node] = victimsMap[node]
+	}
+
+	return result
+}
+
+// tieKey returns what the rules of DefaultPreemption compare of the victims of a candidate. victims not started are
+// taken as started now by DefaultPreemption, i.e. later than any others, so they are keyed the same instead of by the
+// time each candidate is compared.
+func tieKey(victims *extenderv1.Victims) string {
+	if len(victims.Pods) == 0 {
+		return fmt.Sprintf("%d", victims.NumPDBViolations)
+	}
+
+	var (
+		sum         int64
+		maxPriority = corev1helpers.PodPriority(victims.Pods[0])
+		earliest    *metav1.Time
+	)
+	for _, pod := range victims.Pods {
+		priority := corev1helpers.PodPriority(pod)
+		sum += int64(priority)
+		if priority > maxPriority {
+			maxPriority, earliest = priority, nil
+		}
+		if priority == maxPriority && pod.Status.StartTime != nil && (earliest == nil || pod.Status.StartTime.Before(earliest)) {
+			earliest = pod.Status.StartTime
+		}
+	}
+
+	start := "unstarted"
+	if earliest != nil {
+		start = fmt.Sprintf("%d", earliest.UnixNano())
+	}
+
+	return fmt.Sprintf("%d/%d/%d/%d/%s", victims.NumPDBViolations, corev1helpers.PodPriority(victims.Pods[0]), sum, len(victims.Pods), start)
+}
+
+func seededLess(seed int64, node, other string) bool {
+	hash, otherHash := seededHash(seed, node), seededHash(seed, other)
+	if hash != otherHash {
+		return hash < otherHash
+	}
+	return node < other
+}
+
+// useSeededPreemption replaces DefaultPreemption of profile by seededPreemption with the same args,
+// nothing is changed if DefaultPreemption is disabled.
+func useSeededPreemption(profile *kubeschedulerconfig.KubeSchedulerProfile) {
+	for _, disabled := range [][]kubeschedulerconfig.Plugin{profile.Plugins.MultiPoint.Disabled, profile.Plugins.PostFilter.Disabled} {
+		for _, plugin := range disabled {
+			if plugin.Name == defaultpreemption.Name || plugin.Name == "*" {
+				return
+			}
+		}
+	}
+
+	var args runtime.Object = &kubeschedulerconfig.DefaultPreemptionArgs{
+		MinCandidateNodesPercentage: 10,
+		MinCandidateNodesAbsolute:   100,
+	}
+	for _, pluginConfig := range profile.PluginConfig {
+		if pluginConfig.Name == defaultpreemption.Name {
+			args = pluginConfig.Args
+		}
+	}
+
+	profile.Plugins.PostFilter.Enabled = append(profile.Plugins.PostFilter.Enabled, kubeschedulerconfig.Plugin{Name: seededPreemptionName})
+	profile.Plugins.PostFilter.Disabled = append(profile.Plugins.PostFilter.Disabled, kubeschedulerconfig.Plugin{Name: defaultpreemption.Name})
+	profile.PluginConfig = append(profile.PluginConfig, kubeschedulerconfig.PluginConfig{Name: seededPreemptionName, Args: args})
+}
@@ -0,0 +1,187 @@
+package framework
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// PodTrace is the scheduling decision of a pod, it's written as a line of json for each scheduling attempt.
+type PodTrace struct {
+	// namespace/name of the pod
+	Pod           string `json:"pod"`
+	SchedulerName string `json:"schedulerName"`
+	// node selected by the scheduler, empty if the pod could not be scheduled
+	Node  string `json:"node,omitempty"`
+	Error string `json:"error,omitempty"`
+	// set if the pod is rejected by a PreFilter plugin, no node is filtered then
+	PreFilter *PluginVerdict `json:"preFilter,omitempty"`
+	// verdicts of the nodes evaluated by the scheduler
+	Filters []NodeFilterTrace `json:"filters,omitempty"`
+	// scores of feasible nodes, not set if there is only one feasible node since nodes are not scored then
+	Scores []NodeScoreTrace `json:"scores,omitempty"`
+}
+
+// PluginVerdict is the failure reported by a plugin.
+type PluginVerdict struct {
+	Plugin  string `json:"plugin"`
+	Message string `json:"message,omitempty"`
+}
+
+// NodeFilterTrace is the result of Filter plugins on a node, plugin and message are set if the node is infeasible.
+type NodeFilterTrace struct {
+	Node     string `json:"node"`
+	Feasible bool   `json:"feasible"`
+	Plugin   string `json:"plugin,omitempty"`
+	Message  string `json:"message,omitempty"`
+}
+
+// NodeScoreTrace is the weighted score of each Score plugin on a node.
+type NodeScoreTrace struct {
+	Node       string           `json:"node"`
+	Scores     map[string]int64 `json:"scores"`
+	TotalScore int64            `json:"totalScore"`
+}
+
+// Tracer writes the traces of pods as NDJSON, it's safe to be shared by simulators running concurrently.
+type Tracer struct {
+	mux     sync.Mutex
+	encoder *json.Encoder
+}
+
+func NewTracer(w io.Writer) *Tracer {
+	return &Tracer{
+		encoder: json.NewEncoder(w),
+	}
+}
+
+func (t *Tracer) Trace(trace *PodTrace) error {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	return t.encoder.Encode(trace)
+}
+
+type schedulePodFunc func(ctx context.Context, fwk framework.Framework, state *framework.CycleState, pod *corev1.Pod) (scheduler.ScheduleResult, error)
+
+// traceSchedulePod wraps schedulePod to trace the decision of each pod. The verdicts are recorded from the real
+// scheduling cycle: failures are taken from the FitError if the pod could not be scheduled, otherwise from the Filter
+// plugins run by the cycle, and scores from the Score plugins, so only the nodes evaluated by the scheduler are traced.
+func (s *kubeschedulerFramework) traceSchedulePod(schedulePod schedulePodFunc) schedulePodFunc {
+	return func(ctx context.Context, fwk framework.Framework, state *framework.CycleState, pod *corev1.Pod) (scheduler.ScheduleResult, error) {
+		recorder := &traceRecorder{
+			Framework: fwk,
+			filters:   make(map[string]NodeFilterTrace),
+		}
+		result, err := schedulePod(ctx, recorder, state, pod)
+
+		trace := recorder.trace(pod, err)
+		trace.Node = result.SuggestedHost
+		if err := s.tracer.Trace(trace); err != nil {
+			klog.ErrorS(err, "Failed to write trace of pod", "pod", klog.KObj(pod))
+		}
+
+		return result, err
+	}
+}
+
+// traceRecorder records the Filter and Score results of a scheduling cycle, Filter plugins are run on nodes in parallel.
+type traceRecorder struct {
+	framework.Framework
+
+	mux     sync.Mutex
+	filters map[string]NodeFilterTrace
+	scores  []framework.NodePluginScores
+}
+
+func (r *traceRecorder) RunFilterPluginsWithNominatedPods(ctx context.Context, state *framework.CycleState, pod *corev1.Pod, info *framework.NodeInfo) *framework.Status {
+	status := r.Framework.RunFilterPluginsWithNominatedPods(ctx, state, pod, info)
+
+	filter := NodeFilterTrace{
+		Node:     info.Node().Name,
+		Feasible: status.IsSuccess(),
+	}
+	if !status.IsSuccess() {
+		filter.Plugin = status.FailedPlugin()
+		filter.Message = status.Message()
+	}
+
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.filters[filter.Node] = filter
+
+	return status
+}
+
+func (r *traceRecorder) RunScorePlugins(ctx context.Context, state *framework.CycleState, pod *corev1.Pod, nodes []*corev1.Node) ([]framework.NodePluginScores, *framework.Status) {
+	scores, status := r.Framework.RunScorePlugins(ctx, state, pod, nodes)
+	if status.IsSuccess() {
+		r.scores = scores
+	}
+
+	return scores, status
+}
+
+// trace returns the trace of pod with the results recorded, nodes are sorted by name.
+func (r *traceRecorder) trace(pod *corev1.Pod, err error) *PodTrace {
+	trace := &PodTrace{
+		Pod:           pod.Namespace + "/" + pod.Name,
+		SchedulerName: r.ProfileName(),
+	}
+	if err != nil {
+		trace.Error = err.Error()
+	}
+
+	if fitErr, ok := err.(*framework.FitError); ok {
+		if len(fitErr.Diagnosis.PreFilterMsg) > 0 {
+			trace.PreFilter = &PluginVerdict{
+				Plugin:  strings.Join(fitErr.Diagnosis.UnschedulablePlugins.List(), ","),
+				Message: fitErr.Diagnosis.PreFilterMsg,
+			}
+			return trace
+		}
+
+		// the diagnosis also covers the nodes rejected by extenders
+		for node, status := range fitErr.Diagnosis.NodeToStatusMap {
+			trace.Filters = append(trace.Filters, NodeFilterTrace{
+				Node:    node,
+				Plugin:  status.FailedPlugin(),
+				Message: status.Message(),
+			})
+		}
+	} else {
+		r.mux.Lock()
+		for _, filter := range r.filters {
+			trace.Filters = append(trace.Filters, filter)
+		}
+		r.mux.Unlock()
+	}
+	sort.Slice(trace.Filters, func(i, j int) bool {
+		return trace.Filters[i].Node < trace.Filters[j].Node
+	})
+
+	for _, nodeScore := range r.scores {
+		score := NodeScoreTrace{
+			Node:       nodeScore.Name,
+			Scores:     make(map[string]int64, len(nodeScore.Scores)),
+			TotalScore: nodeScore.TotalScore,
+		}
+		for _, pluginScore := range nodeScore.Scores {
+			score.Scores[pluginScore.Name] = pluginScore.Score
+		}
+		trace.Scores = append(trace.Scores, score)
+	}
+	sort.Slice(trace.Scores, func(i, j int) bool {
+		return trace.Scores[i].Node < trace.Scores[j].Node
+	})
+
+	return trace
+}
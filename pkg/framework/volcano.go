@@ -1 +1,194 @@
+/*
+Copyright © 2023 k-cloud-labs org
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
 package framework
+
+import (
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	restclient "k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+	schedconfig "k8s.io/kubernetes/cmd/kube-scheduler/app/config"
+	kubeschedulerconfig "k8s.io/kubernetes/pkg/scheduler/apis/config"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+
+	"github.com/k-cloud-labs/kluster-capacity/pkg"
+	"github.com/k-cloud-labs/kluster-capacity/pkg/plugins/volcano"
+)
+
+// volcanoFramework simulates volcano scheduler, it reuses kube-scheduler framework for filter and score
+// and respects queue, PodGroup and gang semantics of volcano by the VolcanoGang plugin.
+type volcanoFramework struct {
+	*kubeschedulerFramework
+
+	cache *volcano.Cache
+	// index of gangs generated for simulation
+	gangIndex int
+}
+
+// NewVolcanoFramework create a volcano simulator for ce, cc, ss simulator, volcano PodGroups and Queues are copied
+// from the cluster or taken from the objs passed to Initialize as unstructured objects.
+func NewVolcanoFramework(kubeSchedulerConfig *schedconfig.CompletedConfig, restConfig *restclient.Config, options ...Option) (pkg.Framework, error) {
+	volcanoCache := volcano.NewCache()
+
+	for i := range kubeSchedulerConfig.ComponentConfig.Profiles {
+		plugins := kubeSchedulerConfig.ComponentConfig.Profiles[i].Plugins
+		plugins.MultiPoint.Enabled = append(plugins.MultiPoint.Enabled, kubeschedulerconfig.Plugin{Name: volcano.Name})
+	}
+
+	// the plugin is registered after all options are applied, so that it's created with the gang wait time set
+	options = append(options, func(s *kubeschedulerFramework) {
+		withPlugin(volcano.Name, func(configuration runtime.Object, f framework.Handle) (framework.Plugin, error) {
+			return volcano.New(volcanoCache, f, s.gangWaitTime)
+		})(s)
+	})
+
+	s, err := newKubeSchedulerFramework(kubeSchedulerConfig, restConfig, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	// pods reserved are assumed by the plugin, bound pods are assumed here so that pods of the world are counted too
+	_, _ = kubeSchedulerConfig.InformerFactory.Core().V1().Pods().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if pod, ok := obj.(*corev1.Pod); ok && len(pod.Spec.NodeName) > 0 {
+				volcanoCache.AssumePod(pod)
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			if pod, ok := newObj.(*corev1.Pod); ok && len(pod.Spec.NodeName) > 0 {
+				volcanoCache.AssumePod(pod)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if pod, ok := obj.(*corev1.Pod); ok {
+				volcanoCache.ForgetPod(pod)
+			}
+		},
+	})
+
+	return &volcanoFramework{
+		kubeschedulerFramework: s,
+		cache:                  volcanoCache,
+	}, nil
+}
+
+func (s *volcanoFramework) Initialize(objs ...runtime.Object) error {
+	if len(objs) == 0 {
+		if s.dynamicClient == nil {
+			return errors.New("no objects to initialize the world and no cluster to copy from")
+		}
+
+		volcanoObjs, warnings, err := listVolcanoObjects(s.restMapper, s.dynamicClient, s.tolerant)
+		if err != nil {
+			return err
+		}
+		s.status.Warnings = append(s.status.Warnings, warnings...)
+		for _, obj := range volcanoObjs {
+			if _, err := s.cache.Add(obj); err != nil {
+				return err
+			}
+		}
+
+		return s.kubeschedulerFramework.Initialize()
+	}
+
+	var rest []runtime.Object
+	for _, obj := range objs {
+		added, err := s.cache.Add(obj)
+		if err != nil {
+			return err
+		}
+		if !added {
+			rest = append(rest, obj)
+		}
+	}
+
+	return s.kubeschedulerFramework.Initialize(rest...)
+}
+
+// GeneratePodGang generates pods of a new gang, the gang is a copy of the PodGroup the generated pod belongs to,
+// so minMember pods are generated and they are scheduled together.
+func (s *volcanoFramework) GeneratePodGang(generate func() *corev1.Pod) []*corev1.Pod {
+	pod := generate()
+	pg, _ := s.cache.PodGroupOf(pod)
+	if pg == nil || pg.MinMember <= 1 {
+		return []*corev1.Pod{pod}
+	}
+
+	s.gangIndex++
+	gang := &volcano.PodGroup{
+		Namespace: pg.Namespace,
+		Name:      fmt.Sprintf("%s-%d", pg.Name, s.gangIndex),
+		MinMember: pg.MinMember,
+		Queue:     pg.Queue,
+	}
+	s.cache.AddPodGroup(gang)
+
+	pods := []*corev1.Pod{pod}
+	for len(pods) < int(pg.MinMember) {
+		pods = append(pods, generate())
+	}
+	for _, p := range pods {
+		volcano.SetGroupName(p, gang.Name)
+	}
+
+	return pods
+}
+
+// listVolcanoObjects lists PodGroups and Queues from cluster, nothing is returned if volcano is not installed. they are
+// skipped with warnings in tolerant mode if the user is not allowed to list them.
+func listVolcanoObjects(restMapper meta.RESTMapper, dynClient dynamic.Interface, tolerant bool) ([]runtime.Object, []string, error) {
+	var (
+		objs     []runtime.Object
+		warnings []string
+	)
+	for _, gvk := range []schema.GroupVersionKind{volcano.PodGroupGVK, volcano.QueueGVK} {
+		restMapping, err := restMapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			if meta.IsNoMatchError(err) {
+				klog.V(2).InfoS("Volcano resource not found in cluster", "gvk", gvk.String())
+				continue
+			}
+			return nil, nil, &InitError{GVK: gvk, Op: "get rest mapping for", Err: err}
+		}
+
+		items, err := listItems(dynClient, restMapping, metav1.NamespaceAll, metav1.ListOptions{ResourceVersion: "0"})
+		if err != nil {
+			if tolerant && apierrors.IsForbidden(err) {
+				klog.V(2).InfoS("Skip volcano resource", "gvk", gvk.String(), "err", err)
+				warnings = append(warnings, fmt.Sprintf("%s is skipped since it is forbidden to list", gvk.Kind))
+				continue
+			}
+			return nil, nil, &InitError{GVK: gvk, Op: "list", Err: err}
+		}
+		objs = append(objs, items...)
+	}
+
+	return objs, warnings, nil
+}
@@ -0,0 +1,391 @@
+package framework
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/watch"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	clientsetscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/testing"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// World is the objects a simulation starts from. it's immutable once initialized, so it could be shared by frameworks
+// simulating concurrently, e.g. one for each pod template of ce, and each of them only stores the objects changed in
+// its own simulation instead of a full copy of the world.
+type World struct {
+	once sync.Once
+	err  error
+
+	objects map[schema.GroupVersionResource]map[types.NamespacedName]runtime.Object
+	// keys of pods indexed by the node they are bound to
+	podsByNode map[string][]types.NamespacedName
+	warnings   []string
+}
+
+func NewWorld() *World {
+	return &World{
+		objects:    make(map[schema.GroupVersionResource]map[types.NamespacedName]runtime.Object),
+		podsByNode: make(map[string][]types.NamespacedName),
+	}
+}
+
+// init initializes the world by initFn only once, the error of the first call is returned to all callers.
+func (w *World) init(initFn func() error) error {
+	w.once.Do(func() {
+		w.err = initFn()
+	})
+
+	return w.err
+}
+
+// add adds obj to the world, obj is kept as is instead of a copy and must not be modified any more.
+func (w *World) add(obj runtime.Object) error {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	gvks, _, err := clientsetscheme.Scheme.ObjectKinds(obj)
+	if err != nil {
+		return err
+	}
+
+	for _, gvk := range gvks {
+		gvr, _ := meta.UnsafeGuessKindToResource(gvk)
+		if w.objects[gvr] == nil {
+			w.objects[gvr] = make(map[types.NamespacedName]runtime.Object)
+		}
+
+		key := types.NamespacedName{Namespace: accessor.GetNamespace(), Name: accessor.GetName()}
+		if _, ok := w.objects[gvr][key]; ok {
+			return apierrors.NewAlreadyExists(gvr.GroupResource(), accessor.GetName())
+		}
+		w.objects[gvr][key] = obj
+		if nodeName := boundNodeOf(gvr, obj); len(nodeName) > 0 {
+			w.podsByNode[nodeName] = append(w.podsByNode[nodeName], key)
+		}
+	}
+
+	return nil
+}
+
+var (
+	podsGVR  = corev1.SchemeGroupVersion.WithResource("pods")
+	nodesGVR = corev1.SchemeGroupVersion.WithResource("nodes")
+)
+
+// NodeInfos returns the nodes of the world together with the pods bound to them sorted by name, i.e. what the
+// scheduler sees when a simulation starts, so that the world could be analyzed the same way after simulations.
+// the nodes and pods are shared and must not be modified.
+func (w *World) NodeInfos() []*framework.NodeInfo {
+	nodeInfos := make([]*framework.NodeInfo, 0, len(w.objects[nodesGVR]))
+	for _, obj := range w.objects[nodesGVR] {
+		node := obj.(*corev1.Node)
+		nodeInfo := framework.NewNodeInfo()
+		nodeInfo.SetNode(node)
+		for _, key := range w.podsByNode[node.Name] {
+			nodeInfo.AddPod(w.objects[podsGVR][key].(*corev1.Pod))
+		}
+		nodeInfos = append(nodeInfos, nodeInfo)
+	}
+	sort.Slice(nodeInfos, func(i, j int) bool {
+		return nodeInfos[i].Node().Name < nodeInfos[j].Node().Name
+	})
+
+	return nodeInfos
+}
+
+// boundNodeOf returns the node obj is bound to if it's a pod.
+func boundNodeOf(gvr schema.GroupVersionResource, obj runtime.Object) string {
+	if pod, ok := obj.(*corev1.Pod); ok && gvr == podsGVR {
+		return pod.Spec.NodeName
+	}
+
+	return ""
+}
+
+// worldTracker is the ObjectTracker of a fake clientset reading objects from a shared world, the objects created,
+// updated or deleted by the simulation are only stored in the tracker itself. unlike the tracker of client-go,
+// objects are only copied when they are stored or got, objects listed or watched are shared and must not be modified,
+// which is already true for objects of informers, so that large worlds are not copied again and again.
+type worldTracker struct {
+	world *World
+
+	lock sync.RWMutex
+	// objects changed by the simulation, nil if the object of the world is deleted
+	objects map[schema.GroupVersionResource]map[types.NamespacedName]runtime.Object
+	// keys of pods in objects indexed by the node they are bound to
+	podsByNode map[string]sets.Set[types.NamespacedName]
+	watchers   map[schema.GroupVersionResource]map[string][]*watch.RaceFreeFakeWatcher
+}
+
+var _ testing.ObjectTracker = &worldTracker{}
+
+func newWorldTracker(world *World) *worldTracker {
+	return &worldTracker{
+		world:      world,
+		objects:    make(map[schema.GroupVersionResource]map[types.NamespacedName]runtime.Object),
+		podsByNode: make(map[string]sets.Set[types.NamespacedName]),
+		watchers:   make(map[schema.GroupVersionResource]map[string][]*watch.RaceFreeFakeWatcher),
+	}
+}
+
+// useTracker makes client serve all requests from tracker instead of its own one.
+func useTracker(client clientset.Interface, tracker testing.ObjectTracker) error {
+	fakeClient, ok := client.(*fake.Clientset)
+	if !ok {
+		return errors.New("client of scheduler must be a fake clientset")
+	}
+
+	fakeClient.ReactionChain = nil
+	fakeClient.WatchReactionChain = nil
+	fakeClient.AddReactor("*", "*", testing.ObjectReaction(tracker))
+	fakeClient.AddWatchReactor("*", func(action testing.Action) (bool, watch.Interface, error) {
+		w, err := tracker.Watch(action.GetResource(), action.GetNamespace())
+		if err != nil {
+			return false, nil, err
+		}
+		return true, w, nil
+	})
+
+	return nil
+}
+
+// get returns the current object of gvr with key, the caller must hold the lock.
+func (t *worldTracker) get(gvr schema.GroupVersionResource, key types.NamespacedName) (runtime.Object, bool) {
+	if obj, ok := t.objects[gvr][key]; ok {
+		return obj, obj != nil
+	}
+
+	obj, ok := t.world.objects[gvr][key]
+	return obj, ok
+}
+
+func (t *worldTracker) Get(gvr schema.GroupVersionResource, ns, name string) (runtime.Object, error) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	obj, ok := t.get(gvr, types.NamespacedName{Namespace: ns, Name: name})
+	if !ok {
+		return nil, apierrors.NewNotFound(gvr.GroupResource(), name)
+	}
+
+	return obj.DeepCopyObject(), nil
+}
+
+// List lists objects of gvr in ns sorted by namespace and name.
+func (t *worldTracker) List(gvr schema.GroupVersionResource, gvk schema.GroupVersionKind, ns string) (runtime.Object, error) {
+	listGVK := gvk
+	listGVK.Kind = listGVK.Kind + "List"
+	if listGVK.Version == "" {
+		listGVK.Version = runtime.APIVersionInternal
+	}
+
+	list, err := clientsetscheme.Scheme.New(listGVK)
+	if err != nil {
+		return nil, err
+	}
+	if !meta.IsListType(list) {
+		return nil, fmt.Errorf("%q is not a list type", listGVK.Kind)
+	}
+
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	var objs []runtime.Object
+	for key, obj := range t.world.objects[gvr] {
+		if _, changed := t.objects[gvr][key]; !changed && (len(ns) == 0 || key.Namespace == ns) {
+			objs = append(objs, obj)
+		}
+	}
+	for key, obj := range t.objects[gvr] {
+		if obj != nil && (len(ns) == 0 || key.Namespace == ns) {
+			objs = append(objs, obj)
+		}
+	}
+	sortObjects(objs)
+
+	if err := meta.SetList(list, objs); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (t *worldTracker) Watch(gvr schema.GroupVersionResource, ns string) (watch.Interface, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	fakeWatcher := watch.NewRaceFreeFake()
+	if t.watchers[gvr] == nil {
+		t.watchers[gvr] = make(map[string][]*watch.RaceFreeFakeWatcher)
+	}
+	t.watchers[gvr][ns] = append(t.watchers[gvr][ns], fakeWatcher)
+
+	return fakeWatcher, nil
+}
+
+func (t *worldTracker) Add(obj runtime.Object) error {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	gvks, _, err := clientsetscheme.Scheme.ObjectKinds(obj)
+	if err != nil {
+		return err
+	}
+
+	for _, gvk := range gvks {
+		gvr, _ := meta.UnsafeGuessKindToResource(gvk)
+		if err := t.add(gvr, obj, accessor.GetNamespace(), false); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t *worldTracker) Create(gvr schema.GroupVersionResource, obj runtime.Object, ns string) error {
+	return t.add(gvr, obj, ns, false)
+}
+
+func (t *worldTracker) Update(gvr schema.GroupVersionResource, obj runtime.Object, ns string) error {
+	return t.add(gvr, obj, ns, true)
+}
+
+func (t *worldTracker) add(gvr schema.GroupVersionResource, obj runtime.Object, ns string, replaceExisting bool) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	obj = obj.DeepCopyObject()
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	if len(accessor.GetNamespace()) == 0 {
+		accessor.SetNamespace(ns)
+	}
+	if ns != accessor.GetNamespace() {
+		return apierrors.NewBadRequest(fmt.Sprintf("request namespace does not match object namespace, request: %q object: %q", ns, accessor.GetNamespace()))
+	}
+
+	key := types.NamespacedName{Namespace: accessor.GetNamespace(), Name: accessor.GetName()}
+	_, exists := t.get(gvr, key)
+	if exists && !replaceExisting {
+		return apierrors.NewAlreadyExists(gvr.GroupResource(), accessor.GetName())
+	}
+	if !exists && replaceExisting {
+		return apierrors.NewNotFound(gvr.GroupResource(), accessor.GetName())
+	}
+
+	t.set(gvr, key, obj)
+
+	for _, w := range t.getWatches(gvr, ns) {
+		if exists {
+			w.Modify(obj)
+		} else {
+			w.Add(obj)
+		}
+	}
+
+	return nil
+}
+
+func (t *worldTracker) Delete(gvr schema.GroupVersionResource, ns, name string) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	key := types.NamespacedName{Namespace: ns, Name: name}
+	obj, exists := t.get(gvr, key)
+	if !exists {
+		return apierrors.NewNotFound(gvr.GroupResource(), name)
+	}
+
+	// objects of the world are hidden by a nil object
+	t.set(gvr, key, nil)
+	if _, ok := t.world.objects[gvr][key]; !ok {
+		delete(t.objects[gvr], key)
+	}
+
+	for _, w := range t.getWatches(gvr, ns) {
+		w.Delete(obj)
+	}
+
+	return nil
+}
+
+// set stores obj of gvr with key and keeps the index of pods updated, the caller must hold the lock.
+func (t *worldTracker) set(gvr schema.GroupVersionResource, key types.NamespacedName, obj runtime.Object) {
+	if old, ok := t.objects[gvr][key]; ok && old != nil {
+		if nodeName := boundNodeOf(gvr, old); len(nodeName) > 0 {
+			t.podsByNode[nodeName].Delete(key)
+		}
+	}
+	if nodeName := boundNodeOf(gvr, obj); len(nodeName) > 0 {
+		if t.podsByNode[nodeName] == nil {
+			t.podsByNode[nodeName] = sets.New[types.NamespacedName]()
+		}
+		t.podsByNode[nodeName].Insert(key)
+	}
+
+	if t.objects[gvr] == nil {
+		t.objects[gvr] = make(map[types.NamespacedName]runtime.Object)
+	}
+	t.objects[gvr][key] = obj
+}
+
+// podsOnNode returns the pods bound to node sorted by namespace and name, they are shared and must not be modified.
+func (t *worldTracker) podsOnNode(nodeName string) []*corev1.Pod {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	var objs []runtime.Object
+	for _, key := range t.world.podsByNode[nodeName] {
+		if _, changed := t.objects[podsGVR][key]; !changed {
+			objs = append(objs, t.world.objects[podsGVR][key])
+		}
+	}
+	for key := range t.podsByNode[nodeName] {
+		objs = append(objs, t.objects[podsGVR][key])
+	}
+	sortObjects(objs)
+
+	pods := make([]*corev1.Pod, 0, len(objs))
+	for _, obj := range objs {
+		pods = append(pods, obj.(*corev1.Pod))
+	}
+
+	return pods
+}
+
+// sortObjects sorts objs by namespace and name.
+func sortObjects(objs []runtime.Object) {
+	keys := make(map[runtime.Object]string, len(objs))
+	for _, obj := range objs {
+		accessor, _ := meta.Accessor(obj)
+		keys[obj] = accessor.GetNamespace() + "/" + accessor.GetName()
+	}
+	sort.Slice(objs, func(i, j int) bool {
+		return keys[objs[i]] < keys[objs[j]]
+	})
+}
+
+func (t *worldTracker) getWatches(gvr schema.GroupVersionResource, ns string) []*watch.RaceFreeFakeWatcher {
+	watches := append([]*watch.RaceFreeFakeWatcher{}, t.watchers[gvr][ns]...)
+	if ns != metav1.NamespaceAll {
+		watches = append(watches, t.watchers[gvr][metav1.NamespaceAll]...)
+	}
+
+	return watches
+}
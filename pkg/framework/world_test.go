@@ -0,0 +1,298 @@
+package framework
+
+import (
+	"sort"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestWorldTracker(t *testing.T) {
+	tests := []struct {
+		name string
+		ops  []func(tracker *worldTracker) error
+		// error expected from the last op
+		wantErr func(err error) bool
+		// namespace/name of pods listed in namespace
+		namespace string
+		wantList  []string
+		// namespace/name of pods on each node
+		wantOnNode map[string][]string
+		// keys of pods stored by the tracker itself
+		wantChanged []string
+	}{
+		{
+			name:       "read the world",
+			wantList:   []string{"default/a", "default/b", "other/c"},
+			wantOnNode: map[string][]string{"node-0": {"default/a", "default/b"}, "node-1": {"other/c"}},
+		},
+		{
+			name:       "list in namespace",
+			namespace:  "default",
+			wantList:   []string{"default/a", "default/b"},
+			wantOnNode: map[string][]string{"node-0": {"default/a", "default/b"}},
+		},
+		{
+			name: "create a pod",
+			ops: []func(tracker *worldTracker) error{
+				create(newTestPod("default", "d", "node-0")),
+			},
+			wantList:    []string{"default/a", "default/b", "default/d", "other/c"},
+			wantOnNode:  map[string][]string{"node-0": {"default/a", "default/b", "default/d"}},
+			wantChanged: []string{"default/d"},
+		},
+		{
+			name: "update a pod to another node",
+			ops: []func(tracker *worldTracker) error{
+				update(newTestPod("default", "a", "node-1")),
+			},
+			wantList:    []string{"default/a", "default/b", "other/c"},
+			wantOnNode:  map[string][]string{"node-0": {"default/b"}, "node-1": {"default/a", "other/c"}},
+			wantChanged: []string{"default/a"},
+		},
+		{
+			name: "delete a pod of the world leaves a tombstone",
+			ops: []func(tracker *worldTracker) error{
+				remove("default", "a"),
+			},
+			wantList:    []string{"default/b", "other/c"},
+			wantOnNode:  map[string][]string{"node-0": {"default/b"}},
+			wantChanged: []string{"default/a"},
+		},
+		{
+			name: "create a pod again after deleting it from the world",
+			ops: []func(tracker *worldTracker) error{
+				remove("default", "a"),
+				create(newTestPod("default", "a", "node-1")),
+			},
+			wantList:    []string{"default/a", "default/b", "other/c"},
+			wantOnNode:  map[string][]string{"node-0": {"default/b"}, "node-1": {"default/a", "other/c"}},
+			wantChanged: []string{"default/a"},
+		},
+		{
+			name: "delete a pod created leaves no tombstone",
+			ops: []func(tracker *worldTracker) error{
+				create(newTestPod("default", "d", "node-0")),
+				remove("default", "d"),
+			},
+			wantList:   []string{"default/a", "default/b", "other/c"},
+			wantOnNode: map[string][]string{"node-0": {"default/a", "default/b"}},
+		},
+		{
+			name: "create an existing pod",
+			ops: []func(tracker *worldTracker) error{
+				create(newTestPod("default", "a", "node-1")),
+			},
+			wantErr:    apierrors.IsAlreadyExists,
+			wantList:   []string{"default/a", "default/b", "other/c"},
+			wantOnNode: map[string][]string{"node-0": {"default/a", "default/b"}},
+		},
+		{
+			name: "update a deleted pod",
+			ops: []func(tracker *worldTracker) error{
+				remove("default", "a"),
+				update(newTestPod("default", "a", "node-1")),
+			},
+			wantErr:     apierrors.IsNotFound,
+			wantList:    []string{"default/b", "other/c"},
+			wantOnNode:  map[string][]string{"node-0": {"default/b"}, "node-1": {"other/c"}},
+			wantChanged: []string{"default/a"},
+		},
+		{
+			name: "delete a deleted pod",
+			ops: []func(tracker *worldTracker) error{
+				remove("default", "a"),
+				remove("default", "a"),
+			},
+			wantErr:     apierrors.IsNotFound,
+			wantList:    []string{"default/b", "other/c"},
+			wantChanged: []string{"default/a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			world := newTestWorld(t)
+			tracker := newWorldTracker(world)
+
+			var err error
+			for _, op := range tt.ops {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				err = op(tracker)
+			}
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr != nil && !tt.wantErr(err) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			list, err := tracker.List(podsGVR, podGVK, tt.namespace)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var listed []*corev1.Pod
+			for i := range list.(*corev1.PodList).Items {
+				listed = append(listed, &list.(*corev1.PodList).Items[i])
+			}
+			assertPods(t, "listed", listed, tt.wantList)
+
+			for node, want := range tt.wantOnNode {
+				assertPods(t, "on "+node, tracker.podsOnNode(node), want)
+			}
+
+			var changed []string
+			for key := range tracker.objects[podsGVR] {
+				changed = append(changed, key.String())
+			}
+			sort.Strings(changed)
+			assertKeys(t, "changed", changed, tt.wantChanged)
+
+			// the world is never changed by the tracker
+			assertPods(t, "on node-0 of the world", worldPodsOnNode(world, "node-0"), []string{"default/a", "default/b"})
+		})
+	}
+}
+
+func TestWorldTrackerCopyOnWrite(t *testing.T) {
+	world := newTestWorld(t)
+	tracker := newWorldTracker(world)
+	shared := world.objects[podsGVR][types.NamespacedName{Namespace: "default", Name: "a"}]
+
+	// objects got are copies
+	obj, err := tracker.Get(podsGVR, "default", "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obj == shared {
+		t.Fatal("expected a copy of the pod of the world")
+	}
+	obj.(*corev1.Pod).Spec.NodeName = "node-1"
+	if shared.(*corev1.Pod).Spec.NodeName != "node-0" {
+		t.Errorf("expected the pod of the world not modified, got node %s", shared.(*corev1.Pod).Spec.NodeName)
+	}
+
+	// objects listed and on nodes are read from the world until they are changed, the pods on nodes are even shared
+	list, err := tracker.List(podsGVR, podGVK, "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pod := &list.(*corev1.PodList).Items[0]; pod.Name != "a" || pod.Spec.NodeName != "node-0" {
+		t.Errorf("expected pod a on node-0 listed, got %s on %s", pod.Name, pod.Spec.NodeName)
+	}
+	if pods := tracker.podsOnNode("node-0"); len(pods) == 0 || pods[0] != shared {
+		t.Error("expected the pod of the world shared by podsOnNode")
+	}
+
+	// objects stored are copies, so the callers could keep modifying theirs
+	pod := newTestPod("default", "a", "node-1")
+	if err := tracker.Update(podsGVR, pod, "default"); err != nil {
+		t.Fatal(err)
+	}
+	pod.Spec.NodeName = "node-2"
+	obj, err = tracker.Get(podsGVR, "default", "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nodeName := obj.(*corev1.Pod).Spec.NodeName; nodeName != "node-1" {
+		t.Errorf("expected pod a stored on node-1, got %s", nodeName)
+	}
+	if pods := tracker.podsOnNode("node-1"); len(pods) != 2 || pods[0].Name != "a" || pods[0] == shared || pods[0] == pod {
+		t.Error("expected the pod stored by the tracker on node-1")
+	}
+	if shared.(*corev1.Pod).Spec.NodeName != "node-0" {
+		t.Errorf("expected the pod of the world not modified, got node %s", shared.(*corev1.Pod).Spec.NodeName)
+	}
+
+	// trackers sharing a world don't see the changes of each other
+	other := newWorldTracker(world)
+	obj, err = other.Get(podsGVR, "default", "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nodeName := obj.(*corev1.Pod).Spec.NodeName; nodeName != "node-0" {
+		t.Errorf("expected pod a of another tracker on node-0, got %s", nodeName)
+	}
+}
+
+func create(pod *corev1.Pod) func(tracker *worldTracker) error {
+	return func(tracker *worldTracker) error {
+		return tracker.Create(podsGVR, pod, pod.Namespace)
+	}
+}
+
+func update(pod *corev1.Pod) func(tracker *worldTracker) error {
+	return func(tracker *worldTracker) error {
+		return tracker.Update(podsGVR, pod, pod.Namespace)
+	}
+}
+
+func remove(namespace, name string) func(tracker *worldTracker) error {
+	return func(tracker *worldTracker) error {
+		return tracker.Delete(podsGVR, namespace, name)
+	}
+}
+
+func newTestWorld(t *testing.T) *World {
+	world := NewWorld()
+	for _, pod := range []*corev1.Pod{
+		newTestPod("default", "b", "node-0"),
+		newTestPod("default", "a", "node-0"),
+		newTestPod("other", "c", "node-1"),
+	} {
+		if err := world.add(pod); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return world
+}
+
+func newTestPod(namespace, name, nodeName string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec:       corev1.PodSpec{NodeName: nodeName},
+	}
+}
+
+func worldPodsOnNode(world *World, nodeName string) []*corev1.Pod {
+	var pods []*corev1.Pod
+	for _, key := range world.podsByNode[nodeName] {
+		pods = append(pods, world.objects[podsGVR][key].(*corev1.Pod))
+	}
+	sort.Slice(pods, func(i, j int) bool {
+		return pods[i].Namespace+"/"+pods[i].Name < pods[j].Namespace+"/"+pods[j].Name
+	})
+
+	return pods
+}
+
+func assertPods(t *testing.T, what string, pods []*corev1.Pod, want []string) {
+	t.Helper()
+
+	var keys []string
+	for _, pod := range pods {
+		keys = append(keys, pod.Namespace+"/"+pod.Name)
+	}
+	assertKeys(t, what, keys, want)
+}
+
+func assertKeys(t *testing.T, what string, keys, want []string) {
+	t.Helper()
+
+	if len(keys) != len(want) {
+		t.Errorf("expected %v %v, got %v", what, want, keys)
+		return
+	}
+	for i := range keys {
+		if keys[i] != want[i] {
+			t.Errorf("expected %v %v, got %v", what, want, keys)
+			return
+		}
+	}
+}
@@ -1,13 +1,15 @@
 package pkg
 
 import (
+	"context"
+
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
 // Framework need to be implemented by all scheduler framework
 type Framework interface {
-	Run(init func() error) error
+	Run(ctx context.Context, init func() error) error
 	Initialize(objs ...runtime.Object) error
 	CreatePod(pod *corev1.Pod) error
 	UpdateEstimationPods(pod ...*corev1.Pod)
@@ -17,9 +19,16 @@ type Framework interface {
 	Stop(reason string) error
 }
 
+// GangFramework is implemented by frameworks which schedule pods by gang, e.g. volcano
+type GangFramework interface {
+	Framework
+	// GeneratePodGang generates all pods of a gang, generate is used to create each pod of the gang
+	GeneratePodGang(generate func() *corev1.Pod) []*corev1.Pod
+}
+
 // Simulator need to be implemented by all simulator
 type Simulator interface {
-	Run() error
+	Run(ctx context.Context) error
 	Initialize(objs ...runtime.Object) error
 	Report() Printer
 }
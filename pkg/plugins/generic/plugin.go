@@ -2,6 +2,7 @@ package generic
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	corev1 "k8s.io/api/core/v1"
@@ -17,6 +18,7 @@ const Name = "GenericBinder"
 
 type GenericBinder struct {
 	client       kubernetes.Interface
+	volumeBinder *volumeBinder
 	postBindHook func(*corev1.Pod) error
 	status       *pkg.Status
 }
@@ -25,6 +27,7 @@ func New(postBindHook func(*corev1.Pod) error, client kubernetes.Interface, stat
 	return &GenericBinder{
 		postBindHook: postBindHook,
 		client:       client,
+		volumeBinder: newVolumeBinder(client),
 		status:       status,
 	}, nil
 }
@@ -49,7 +52,16 @@ func (b *GenericBinder) Bind(ctx context.Context, state *framework.CycleState, p
 	return nil
 }
 
+// PreBind binds the pvcs of the pod in the world instead of waiting for pv controller like VolumeBinding plugin does.
 func (b *GenericBinder) PreBind(ctx context.Context, state *framework.CycleState, p *corev1.Pod, nodeName string) *framework.Status {
+	if err := b.volumeBinder.BindPodVolumes(ctx, p, nodeName); err != nil {
+		// the pod is unschedulable instead of failing to bind, so that the storage is never over-committed
+		if errors.Is(err, errInsufficientCapacity) {
+			return framework.NewStatus(framework.Unschedulable, err.Error())
+		}
+		return framework.NewStatus(framework.Error, fmt.Sprintf("Unable to bind volumes: %v", err))
+	}
+
 	return nil
 }
 
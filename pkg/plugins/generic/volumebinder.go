@@ -0,0 +1,314 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/component-helpers/storage/volume"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// annotations set by pv controller when a pvc is bound
+	annBindCompleted      = "pv.kubernetes.io/bind-completed"
+	annBoundByController  = "pv.kubernetes.io/bound-by-controller"
+	annDynamicallyCreated = "pv.kubernetes.io/provisioned-by"
+
+	noProvisioner = "kubernetes.io/no-provisioner"
+)
+
+// errInsufficientCapacity is returned if the storage capacity accessible from the node is tracked but
+// none of it has room for the volume, the pod is unschedulable on the node then.
+var errInsufficientCapacity = errors.New("insufficient storage capacity")
+
+// volumeBinder simulates what pv controller and external provisioners do after a node is selected for the pod,
+// unbound pvcs of the pod are bound to the matching pvs or to virtual pvs provisioned dynamically, and
+// the CSIStorageCapacity used by provisioned pvs is deducted, all in the fake world.
+type volumeBinder struct {
+	client kubernetes.Interface
+	// pods are bound concurrently, but pvs could only be bound once
+	mux sync.Mutex
+}
+
+func newVolumeBinder(client kubernetes.Interface) *volumeBinder {
+	return &volumeBinder{
+		client: client,
+	}
+}
+
+// BindPodVolumes binds all unbound pvcs of pod with the same policy used by VolumeBinding plugin,
+// so that the pvs chosen are the same as those assumed by the scheduler.
+func (b *volumeBinder) BindPodVolumes(ctx context.Context, pod *corev1.Pod, nodeName string) error {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	claims, err := b.unboundClaims(ctx, pod)
+	if err != nil || len(claims) == 0 {
+		return err
+	}
+
+	node, err := b.client.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	pvList, err := b.client.CoreV1().PersistentVolumes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	// smallest claims first, same as VolumeBinding plugin
+	sort.Slice(claims, func(i, j int) bool {
+		iSize := claims[i].Spec.Resources.Requests[corev1.ResourceStorage]
+		jSize := claims[j].Spec.Resources.Requests[corev1.ResourceStorage]
+		return iSize.Cmp(jSize) < 0
+	})
+
+	chosen := make(map[string]*corev1.PersistentVolume)
+	for _, claim := range claims {
+		class := volume.GetPersistentVolumeClaimClass(claim)
+		var pvs []*corev1.PersistentVolume
+		for i := range pvList.Items {
+			if volume.GetPersistentVolumeClass(&pvList.Items[i]) == class {
+				pvs = append(pvs, &pvList.Items[i])
+			}
+		}
+
+		pv, err := volume.FindMatchingVolume(claim, pvs, node, chosen, true)
+		if err != nil {
+			return err
+		}
+		if pv == nil {
+			if pv, err = b.provision(ctx, claim, node); err != nil {
+				return err
+			}
+		} else if pv, err = b.bindVolume(ctx, pv, claim); err != nil {
+			return err
+		}
+		chosen[pv.Name] = pv
+
+		if err := b.bindClaim(ctx, claim, pv); err != nil {
+			return err
+		}
+		klog.V(4).InfoS("Bound pvc of pod", "pod", klog.KObj(pod), "pvc", klog.KObj(claim), "pv", pv.Name, "node", nodeName)
+	}
+
+	return nil
+}
+
+func (b *volumeBinder) unboundClaims(ctx context.Context, pod *corev1.Pod) ([]*corev1.PersistentVolumeClaim, error) {
+	var claims []*corev1.PersistentVolumeClaim
+	for _, vol := range pod.Spec.Volumes {
+		if vol.PersistentVolumeClaim == nil {
+			continue
+		}
+
+		claim, err := b.client.CoreV1().PersistentVolumeClaims(pod.Namespace).Get(ctx, vol.PersistentVolumeClaim.ClaimName, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		if len(claim.Spec.VolumeName) == 0 {
+			claims = append(claims, claim)
+		}
+	}
+
+	return claims, nil
+}
+
+func (b *volumeBinder) bindVolume(ctx context.Context, pv *corev1.PersistentVolume, claim *corev1.PersistentVolumeClaim) (*corev1.PersistentVolume, error) {
+	pv, _, err := volume.GetBindVolumeToClaim(pv, claim)
+	if err != nil {
+		return nil, err
+	}
+	pv.Status.Phase = corev1.VolumeBound
+	bumpResourceVersion(pv)
+
+	return b.client.CoreV1().PersistentVolumes().Update(ctx, pv, metav1.UpdateOptions{})
+}
+
+func (b *volumeBinder) bindClaim(ctx context.Context, claim *corev1.PersistentVolumeClaim, pv *corev1.PersistentVolume) error {
+	claim = claim.DeepCopy()
+	if claim.Annotations == nil {
+		claim.Annotations = map[string]string{}
+	}
+	claim.Annotations[annBindCompleted] = "yes"
+	claim.Annotations[annBoundByController] = "yes"
+	claim.Spec.VolumeName = pv.Name
+	claim.Status.Phase = corev1.ClaimBound
+	claim.Status.AccessModes = pv.Spec.AccessModes
+	claim.Status.Capacity = pv.Spec.Capacity
+	bumpResourceVersion(claim)
+
+	_, err := b.client.CoreV1().PersistentVolumeClaims(claim.Namespace).Update(ctx, claim, metav1.UpdateOptions{})
+	return err
+}
+
+// provision creates a virtual pv for claim, the pv is only accessible from the topology of node
+// if the driver reports topology keys in CSINode.
+func (b *volumeBinder) provision(ctx context.Context, claim *corev1.PersistentVolumeClaim, node *corev1.Node) (*corev1.PersistentVolume, error) {
+	class := volume.GetPersistentVolumeClaimClass(claim)
+	if len(class) == 0 {
+		return nil, fmt.Errorf("no matching volume for pvc %s/%s and no storage class to provision", claim.Namespace, claim.Name)
+	}
+
+	storageClass, err := b.client.StorageV1().StorageClasses().Get(ctx, class, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if storageClass.Provisioner == noProvisioner {
+		return nil, fmt.Errorf("no matching volume for pvc %s/%s and storage class %s could not provision", claim.Namespace, claim.Name, class)
+	}
+
+	size := claim.Spec.Resources.Requests[corev1.ResourceStorage]
+	if err := b.deductCapacity(ctx, storageClass, node, size); err != nil {
+		return nil, err
+	}
+
+	name := "pvc-" + string(claim.UID)
+	if len(claim.UID) == 0 {
+		name = fmt.Sprintf("pvc-%s-%s", claim.Namespace, claim.Name)
+	}
+	pv := &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Annotations: map[string]string{
+				annDynamicallyCreated: storageClass.Provisioner,
+			},
+		},
+		Spec: corev1.PersistentVolumeSpec{
+			Capacity:         corev1.ResourceList{corev1.ResourceStorage: size},
+			AccessModes:      claim.Spec.AccessModes,
+			VolumeMode:       claim.Spec.VolumeMode,
+			StorageClassName: class,
+			MountOptions:     storageClass.MountOptions,
+			PersistentVolumeSource: corev1.PersistentVolumeSource{
+				CSI: &corev1.CSIPersistentVolumeSource{
+					Driver:       storageClass.Provisioner,
+					VolumeHandle: name,
+				},
+			},
+			NodeAffinity: b.topologyOf(ctx, storageClass.Provisioner, node),
+		},
+		Status: corev1.PersistentVolumeStatus{
+			Phase: corev1.VolumeBound,
+		},
+	}
+	if storageClass.ReclaimPolicy != nil {
+		pv.Spec.PersistentVolumeReclaimPolicy = *storageClass.ReclaimPolicy
+	}
+	pv, _, err = volume.GetBindVolumeToClaim(pv, claim)
+	if err != nil {
+		return nil, err
+	}
+	bumpResourceVersion(pv)
+
+	return b.client.CoreV1().PersistentVolumes().Create(ctx, pv, metav1.CreateOptions{})
+}
+
+// topologyOf returns the node affinity of the volume provisioned by driver on node, nil if driver
+// doesn't report topology which means the volume is accessible from all nodes.
+func (b *volumeBinder) topologyOf(ctx context.Context, driver string, node *corev1.Node) *corev1.VolumeNodeAffinity {
+	csiNode, err := b.client.StorageV1().CSINodes().Get(ctx, node.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil
+	}
+
+	for _, d := range csiNode.Spec.Drivers {
+		if d.Name != driver || len(d.TopologyKeys) == 0 {
+			continue
+		}
+
+		var requirements []corev1.NodeSelectorRequirement
+		for _, key := range d.TopologyKeys {
+			if value, ok := node.Labels[key]; ok {
+				requirements = append(requirements, corev1.NodeSelectorRequirement{
+					Key:      key,
+					Operator: corev1.NodeSelectorOpIn,
+					Values:   []string{value},
+				})
+			}
+		}
+		if len(requirements) == 0 {
+			return nil
+		}
+
+		return &corev1.VolumeNodeAffinity{
+			Required: &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{{MatchExpressions: requirements}},
+			},
+		}
+	}
+
+	return nil
+}
+
+// deductCapacity deducts size from the CSIStorageCapacity of storageClass accessible from node,
+// nothing is deducted if storage capacity is not tracked by the driver. errInsufficientCapacity is returned
+// if it's tracked but no capacity has room for size.
+func (b *volumeBinder) deductCapacity(ctx context.Context, storageClass *storagev1.StorageClass, node *corev1.Node, size resource.Quantity) error {
+	capacities, err := b.client.StorageV1().CSIStorageCapacities(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	tracked := b.capacityTracked(ctx, storageClass.Provisioner)
+	for i := range capacities.Items {
+		capacity := &capacities.Items[i]
+		if capacity.StorageClassName != storageClass.Name || capacity.NodeTopology == nil || capacity.Capacity == nil {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(capacity.NodeTopology)
+		if err != nil || !selector.Matches(labels.Set(node.Labels)) {
+			continue
+		}
+		tracked = true
+		if capacity.Capacity.Cmp(size) < 0 {
+			continue
+		}
+
+		left := capacity.Capacity.DeepCopy()
+		left.Sub(size)
+		capacity.Capacity = &left
+		// maximum volume size is preferred by scheduler, it could not exceed the capacity left
+		if capacity.MaximumVolumeSize != nil && capacity.MaximumVolumeSize.Cmp(left) > 0 {
+			maximum := left.DeepCopy()
+			capacity.MaximumVolumeSize = &maximum
+		}
+
+		_, err = b.client.StorageV1().CSIStorageCapacities(capacity.Namespace).Update(ctx, capacity, metav1.UpdateOptions{})
+		return err
+	}
+
+	if tracked {
+		return fmt.Errorf("%w for storage class %s on node %s", errInsufficientCapacity, storageClass.Name, node.Name)
+	}
+
+	return nil
+}
+
+// capacityTracked returns whether the CSIDriver of driver reports storage capacity, the same as VolumeBinding plugin checks.
+func (b *volumeBinder) capacityTracked(ctx context.Context, driver string) bool {
+	csiDriver, err := b.client.StorageV1().CSIDrivers().Get(ctx, driver, metav1.GetOptions{})
+	if err != nil {
+		return false
+	}
+
+	return csiDriver.Spec.StorageCapacity != nil && *csiDriver.Spec.StorageCapacity
+}
+
+// bumpResourceVersion increases the resource version of obj like apiserver does, fake client doesn't maintain it
+// but the assume cache of VolumeBinding plugin relies on it to drop stale pvs and pvcs.
+func bumpResourceVersion(obj metav1.Object) {
+	version, _ := strconv.ParseInt(obj.GetResourceVersion(), 10, 64)
+	obj.SetResourceVersion(strconv.FormatInt(version+1, 10))
+}
@@ -0,0 +1,276 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+const (
+	testNode   = "node-0"
+	testClass  = "csi"
+	testDriver = "csi.example.com"
+	testZone   = "topology.example.com/zone"
+)
+
+func TestBindPodVolumes(t *testing.T) {
+	tests := []struct {
+		name string
+		objs []runtime.Object
+		// substring of the error expected
+		wantErr string
+		// whether the pod is expected to be unschedulable on the node
+		wantUnschedulable bool
+		// name of the pv the claim is expected to be bound to
+		wantPV string
+		// capacity left of the CSIStorageCapacity "capacity", not checked if empty
+		wantCapacity string
+		// zone the provisioned pv is expected to be accessible from, not checked if empty
+		wantZone string
+	}{
+		{
+			name:   "bind the matching static pv",
+			objs:   []runtime.Object{testPV("pv-0", "2Gi")},
+			wantPV: "pv-0",
+		},
+		{
+			name:   "bind the smallest matching static pv",
+			objs:   []runtime.Object{testPV("pv-0", "4Gi"), testPV("pv-1", "1Gi"), testPV("pv-2", "2Gi")},
+			wantPV: "pv-2",
+		},
+		{
+			name:   "provision if no capacity is tracked",
+			objs:   []runtime.Object{testStorageClass(testDriver)},
+			wantPV: "pvc-uid",
+		},
+		{
+			name:         "provision and deduct the capacity",
+			objs:         []runtime.Object{testStorageClass(testDriver), testCSIDriver(true), testCapacity("5Gi")},
+			wantPV:       "pvc-uid",
+			wantCapacity: "3Gi",
+		},
+		{
+			name:              "fail if the capacity has no room",
+			objs:              []runtime.Object{testStorageClass(testDriver), testCSIDriver(true), testCapacity("1Gi")},
+			wantErr:           "insufficient storage capacity",
+			wantUnschedulable: true,
+			wantCapacity:      "1Gi",
+		},
+		{
+			name:              "fail if the capacity is tracked but not reported",
+			objs:              []runtime.Object{testStorageClass(testDriver), testCSIDriver(true)},
+			wantErr:           "insufficient storage capacity",
+			wantUnschedulable: true,
+		},
+		{
+			name:    "fail if the class could not provision",
+			objs:    []runtime.Object{testStorageClass(noProvisioner)},
+			wantErr: "no matching volume",
+		},
+		{
+			name:     "provision in the topology of the node",
+			objs:     []runtime.Object{testStorageClass(testDriver), testCSINode()},
+			wantPV:   "pvc-uid",
+			wantZone: "zone-0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.TODO()
+			objs := append([]runtime.Object{testNodeObj(), testClaim(), testPod()}, tt.objs...)
+			client := fake.NewSimpleClientset(objs...)
+			binder := newVolumeBinder(client)
+
+			err := binder.BindPodVolumes(ctx, testPod(), testNode)
+			switch {
+			case len(tt.wantErr) == 0 && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case len(tt.wantErr) > 0 && err == nil:
+				t.Fatalf("expected error %q, got nil", tt.wantErr)
+			case len(tt.wantErr) > 0 && !strings.Contains(err.Error(), tt.wantErr):
+				t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+			}
+			if unschedulable := errors.Is(err, errInsufficientCapacity); unschedulable != tt.wantUnschedulable {
+				t.Errorf("expected unschedulable %v, got %v", tt.wantUnschedulable, unschedulable)
+			}
+
+			claim, err := client.CoreV1().PersistentVolumeClaims("default").Get(ctx, "claim", metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if claim.Spec.VolumeName != tt.wantPV {
+				t.Errorf("expected claim bound to %q, got %q", tt.wantPV, claim.Spec.VolumeName)
+			}
+
+			if len(tt.wantPV) > 0 {
+				pv, err := client.CoreV1().PersistentVolumes().Get(ctx, tt.wantPV, metav1.GetOptions{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if pv.Spec.ClaimRef == nil || pv.Spec.ClaimRef.Name != "claim" {
+					t.Errorf("expected pv %s bound to claim, got claimRef %v", pv.Name, pv.Spec.ClaimRef)
+				}
+				if len(tt.wantZone) > 0 && !accessibleFrom(pv, tt.wantZone) {
+					t.Errorf("expected pv %s accessible from %s, got %v", pv.Name, tt.wantZone, pv.Spec.NodeAffinity)
+				}
+			} else {
+				pvs, err := client.CoreV1().PersistentVolumes().List(ctx, metav1.ListOptions{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, pv := range pvs.Items {
+					if pv.Spec.ClaimRef != nil {
+						t.Errorf("expected no pv bound, got %s", pv.Name)
+					}
+				}
+			}
+
+			if len(tt.wantCapacity) > 0 {
+				capacity, err := client.StorageV1().CSIStorageCapacities("default").Get(ctx, "capacity", metav1.GetOptions{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				want := resource.MustParse(tt.wantCapacity)
+				if capacity.Capacity.Cmp(want) != 0 {
+					t.Errorf("expected capacity %s left, got %s", want.String(), capacity.Capacity.String())
+				}
+			}
+		})
+	}
+}
+
+func TestBindPodVolumesSkipsBoundClaims(t *testing.T) {
+	claim := testClaim()
+	claim.Spec.VolumeName = "pv-bound"
+	client := fake.NewSimpleClientset(testNodeObj(), claim, testPV("pv-0", "2Gi"))
+
+	if err := newVolumeBinder(client).BindPodVolumes(context.TODO(), testPod(), testNode); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	pv, err := client.CoreV1().PersistentVolumes().Get(context.TODO(), "pv-0", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pv.Spec.ClaimRef != nil {
+		t.Errorf("expected pv-0 not bound, got claimRef %v", pv.Spec.ClaimRef)
+	}
+}
+
+func TestPreBindInsufficientCapacity(t *testing.T) {
+	client := fake.NewSimpleClientset(testNodeObj(), testClaim(), testStorageClass(testDriver), testCSIDriver(true), testCapacity("1Gi"))
+	plugin, _ := New(nil, client, nil)
+
+	status := plugin.(*GenericBinder).PreBind(context.TODO(), nil, testPod(), testNode)
+	if status.Code() != framework.Unschedulable {
+		t.Errorf("expected status %v, got %v: %v", framework.Unschedulable, status.Code(), status.Message())
+	}
+}
+
+func accessibleFrom(pv *corev1.PersistentVolume, zone string) bool {
+	if pv.Spec.NodeAffinity == nil || pv.Spec.NodeAffinity.Required == nil {
+		return false
+	}
+	for _, term := range pv.Spec.NodeAffinity.Required.NodeSelectorTerms {
+		for _, requirement := range term.MatchExpressions {
+			if requirement.Key == testZone && len(requirement.Values) == 1 && requirement.Values[0] == zone {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func testNodeObj() *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   testNode,
+			Labels: map[string]string{testZone: "zone-0"},
+		},
+	}
+}
+
+func testPod() *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"},
+		Spec: corev1.PodSpec{
+			Volumes: []corev1.Volume{{
+				Name: "data",
+				VolumeSource: corev1.VolumeSource{
+					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "claim"},
+				},
+			}},
+		},
+	}
+}
+
+func testClaim() *corev1.PersistentVolumeClaim {
+	class := testClass
+	return &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "claim", Namespace: "default", UID: "uid"},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			StorageClassName: &class,
+			AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("2Gi")},
+			},
+		},
+	}
+}
+
+func testPV(name, size string) *corev1.PersistentVolume {
+	return &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: corev1.PersistentVolumeSpec{
+			Capacity:         corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(size)},
+			AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			StorageClassName: testClass,
+		},
+		Status: corev1.PersistentVolumeStatus{Phase: corev1.VolumeAvailable},
+	}
+}
+
+func testStorageClass(provisioner string) *storagev1.StorageClass {
+	return &storagev1.StorageClass{
+		ObjectMeta:  metav1.ObjectMeta{Name: testClass},
+		Provisioner: provisioner,
+	}
+}
+
+func testCSIDriver(storageCapacity bool) *storagev1.CSIDriver {
+	return &storagev1.CSIDriver{
+		ObjectMeta: metav1.ObjectMeta{Name: testDriver},
+		Spec:       storagev1.CSIDriverSpec{StorageCapacity: &storageCapacity},
+	}
+}
+
+func testCapacity(size string) *storagev1.CSIStorageCapacity {
+	capacity := resource.MustParse(size)
+	return &storagev1.CSIStorageCapacity{
+		ObjectMeta:       metav1.ObjectMeta{Name: "capacity", Namespace: "default"},
+		StorageClassName: testClass,
+		NodeTopology: &metav1.LabelSelector{
+			MatchLabels: map[string]string{testZone: "zone-0"},
+		},
+		Capacity: &capacity,
+	}
+}
+
+func testCSINode() *storagev1.CSINode {
+	return &storagev1.CSINode{
+		ObjectMeta: metav1.ObjectMeta{Name: testNode},
+		Spec: storagev1.CSINodeSpec{
+			Drivers: []storagev1.CSINodeDriver{{Name: testDriver, NodeID: testNode, TopologyKeys: []string{testZone}}},
+		},
+	}
+}
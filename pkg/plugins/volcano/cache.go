@@ -0,0 +1,258 @@
+package volcano
+
+import (
+	"fmt"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+
+	"github.com/k-cloud-labs/kluster-capacity/pkg/utils"
+)
+
+const (
+	// KubeGroupNameAnnotationKey is the annotation key of pod to identify which PodGroup it belongs to
+	KubeGroupNameAnnotationKey = "scheduling.k8s.io/group-name"
+	// VolcanoGroupNameAnnotationKey is the annotation key of pod to identify which PodGroup it belongs to
+	VolcanoGroupNameAnnotationKey = "scheduling.volcano.sh/group-name"
+
+	// DefaultQueue is the queue used by PodGroup which doesn't specify queue
+	DefaultQueue = "default"
+	// QueueStateClosed means no more pods could be scheduled in the queue
+	QueueStateClosed = "Closed"
+)
+
+var (
+	// PodGroupGVK is the gvk of volcano PodGroup
+	PodGroupGVK = schema.GroupVersionKind{Group: "scheduling.volcano.sh", Version: "v1beta1", Kind: "PodGroup"}
+	// QueueGVK is the gvk of volcano Queue
+	QueueGVK = schema.GroupVersionKind{Group: "scheduling.volcano.sh", Version: "v1beta1", Kind: "Queue"}
+)
+
+// PodGroup is the part of volcano PodGroup used by simulation
+type PodGroup struct {
+	Namespace string
+	Name      string
+	MinMember int32
+	Queue     string
+}
+
+// Queue is the part of volcano Queue used by simulation
+type Queue struct {
+	Name       string
+	Capability corev1.ResourceList
+	State      string
+}
+
+// Cache holds all PodGroups and Queues of the world, volcano objects are CRDs so they are not
+// stored in the fake clientset. pods reserved or bound are indexed by PodGroup and Queue so that gangs and
+// queues are checked without scanning all pods of the world.
+type Cache struct {
+	mux       sync.RWMutex
+	podGroups map[string]*PodGroup
+	queues    map[string]*Queue
+	// pods assumed keyed by namespace/name of the pod
+	assumed map[string]*assumedPod
+	// number of pods assumed of each PodGroup keyed by namespace/name of the PodGroup
+	members map[string]int
+	// requests of pods assumed of each Queue
+	allocated map[string]*framework.Resource
+}
+
+type assumedPod struct {
+	podGroup string
+	queue    string
+	request  *framework.Resource
+}
+
+func NewCache() *Cache {
+	return &Cache{
+		podGroups: make(map[string]*PodGroup),
+		queues:    make(map[string]*Queue),
+		assumed:   make(map[string]*assumedPod),
+		members:   make(map[string]int),
+		allocated: make(map[string]*framework.Resource),
+	}
+}
+
+// Add adds obj to cache if it's a volcano PodGroup or Queue, returns false if obj is not a volcano object.
+func (c *Cache) Add(obj runtime.Object) (bool, error) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return false, nil
+	}
+
+	switch u.GroupVersionKind().GroupKind() {
+	case PodGroupGVK.GroupKind():
+		minMember, _, err := unstructured.NestedInt64(u.Object, "spec", "minMember")
+		if err != nil {
+			return true, err
+		}
+		queue, _, err := unstructured.NestedString(u.Object, "spec", "queue")
+		if err != nil {
+			return true, err
+		}
+		if len(queue) == 0 {
+			queue = DefaultQueue
+		}
+
+		c.AddPodGroup(&PodGroup{
+			Namespace: u.GetNamespace(),
+			Name:      u.GetName(),
+			MinMember: int32(minMember),
+			Queue:     queue,
+		})
+	case QueueGVK.GroupKind():
+		capability, _, err := unstructured.NestedMap(u.Object, "spec", "capability")
+		if err != nil {
+			return true, err
+		}
+		state, _, err := unstructured.NestedString(u.Object, "status", "state")
+		if err != nil {
+			return true, err
+		}
+
+		queue := &Queue{
+			Name:       u.GetName(),
+			Capability: corev1.ResourceList{},
+			State:      state,
+		}
+		for name, quantity := range capability {
+			// quantity could be either string or number
+			q, err := resource.ParseQuantity(fmt.Sprint(quantity))
+			if err != nil {
+				return true, err
+			}
+			queue.Capability[corev1.ResourceName(name)] = q
+		}
+
+		c.mux.Lock()
+		c.queues[queue.Name] = queue
+		c.mux.Unlock()
+	default:
+		return false, nil
+	}
+
+	return true, nil
+}
+
+func (c *Cache) AddPodGroup(pg *PodGroup) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	c.podGroups[pg.Namespace+"/"+pg.Name] = pg
+}
+
+// PodGroupOf returns the PodGroup pod belongs to, nil if pod doesn't belong to any PodGroup.
+// the second return value is false if pod declares a PodGroup which doesn't exist.
+func (c *Cache) PodGroupOf(pod *corev1.Pod) (*PodGroup, bool) {
+	name := GroupName(pod)
+	if len(name) == 0 {
+		return nil, true
+	}
+
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+
+	pg, ok := c.podGroups[pod.Namespace+"/"+name]
+	return pg, ok
+}
+
+// AssumePod records pod as a member of its PodGroup which uses resources of the Queue, e.g. when it's reserved or
+// bound. it's a no-op if pod is assumed already or doesn't belong to any PodGroup.
+func (c *Cache) AssumePod(pod *corev1.Pod) {
+	pg, _ := c.PodGroupOf(pod)
+	if pg == nil {
+		return
+	}
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	key := pod.Namespace + "/" + pod.Name
+	if _, ok := c.assumed[key]; ok {
+		return
+	}
+
+	assumed := &assumedPod{
+		podGroup: pg.Namespace + "/" + pg.Name,
+		queue:    pg.Queue,
+		request:  utils.ComputePodResourceRequest(pod),
+	}
+	c.assumed[key] = assumed
+	c.members[assumed.podGroup]++
+	if _, ok := c.allocated[assumed.queue]; !ok {
+		c.allocated[assumed.queue] = &framework.Resource{}
+	}
+	addResource(c.allocated[assumed.queue], assumed.request)
+}
+
+// ForgetPod reverts AssumePod, e.g. when pod is unreserved or deleted.
+func (c *Cache) ForgetPod(pod *corev1.Pod) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	key := pod.Namespace + "/" + pod.Name
+	assumed, ok := c.assumed[key]
+	if !ok {
+		return
+	}
+
+	delete(c.assumed, key)
+	c.members[assumed.podGroup]--
+	subResource(c.allocated[assumed.queue], assumed.request)
+}
+
+// Members returns the number of pods assumed of pg.
+func (c *Cache) Members(pg *PodGroup) int {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+
+	return c.members[pg.Namespace+"/"+pg.Name]
+}
+
+// Allocated returns a copy of the requests of pods assumed of the queue.
+func (c *Cache) Allocated(queue string) *framework.Resource {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+
+	result := &framework.Resource{}
+	if allocated, ok := c.allocated[queue]; ok {
+		addResource(result, allocated)
+	}
+
+	return result
+}
+
+func (c *Cache) Queue(name string) *Queue {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+
+	return c.queues[name]
+}
+
+// GroupName returns the name of PodGroup pod belongs to.
+func GroupName(pod *corev1.Pod) string {
+	if name, ok := pod.Annotations[VolcanoGroupNameAnnotationKey]; ok {
+		return name
+	}
+
+	return pod.Annotations[KubeGroupNameAnnotationKey]
+}
+
+// SetGroupName sets the name of PodGroup pod belongs to.
+func SetGroupName(pod *corev1.Pod, name string) {
+	if pod.Annotations == nil {
+		pod.Annotations = map[string]string{}
+	}
+	if _, ok := pod.Annotations[VolcanoGroupNameAnnotationKey]; ok {
+		pod.Annotations[VolcanoGroupNameAnnotationKey] = name
+		return
+	}
+
+	pod.Annotations[KubeGroupNameAnnotationKey] = name
+}
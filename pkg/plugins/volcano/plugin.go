@@ -0,0 +1,154 @@
+package volcano
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+
+	"github.com/k-cloud-labs/kluster-capacity/pkg/utils"
+)
+
+const (
+	Name = "VolcanoGang"
+
+	// DefaultGangWaitTime is the max time a pod waits for the other members of its PodGroup by default
+	DefaultGangWaitTime = 10 * time.Second
+)
+
+// Volcano simulates the queue and gang semantics of volcano scheduler on top of kube-scheduler framework:
+// pods of a PodGroup are only bound when at least minMember of them could be scheduled,
+// and pods of a Queue could not use more resources than the capability of the Queue.
+type Volcano struct {
+	cache  *Cache
+	handle framework.Handle
+	// max time a pod waits for the other members of its PodGroup
+	waitTime time.Duration
+}
+
+var (
+	_ framework.PreFilterPlugin = &Volcano{}
+	_ framework.ReservePlugin   = &Volcano{}
+	_ framework.PermitPlugin    = &Volcano{}
+)
+
+// New creates the plugin, pods wait for the other members of their PodGroups for waitTime, DefaultGangWaitTime is
+// used if it's not positive.
+func New(cache *Cache, handle framework.Handle, waitTime time.Duration) (framework.Plugin, error) {
+	if waitTime <= 0 {
+		waitTime = DefaultGangWaitTime
+	}
+
+	return &Volcano{
+		cache:    cache,
+		handle:   handle,
+		waitTime: waitTime,
+	}, nil
+}
+
+func (v *Volcano) Name() string {
+	return Name
+}
+
+func (v *Volcano) PreFilter(ctx context.Context, state *framework.CycleState, pod *corev1.Pod) (*framework.PreFilterResult, *framework.Status) {
+	pg, ok := v.cache.PodGroupOf(pod)
+	if !ok {
+		return nil, framework.NewStatus(framework.UnschedulableAndUnresolvable, fmt.Sprintf("podgroup %s/%s not found", pod.Namespace, GroupName(pod)))
+	}
+	if pg == nil {
+		return nil, nil
+	}
+
+	queue := v.cache.Queue(pg.Queue)
+	if queue == nil {
+		return nil, nil
+	}
+	if queue.State == QueueStateClosed {
+		return nil, framework.NewStatus(framework.UnschedulableAndUnresolvable, fmt.Sprintf("queue %s is closed", queue.Name))
+	}
+	if len(queue.Capability) == 0 {
+		return nil, nil
+	}
+
+	allocated := v.cache.Allocated(queue.Name)
+	addResource(allocated, utils.ComputePodResourceRequest(pod))
+
+	for name, capability := range queue.Capability {
+		if exceeds(allocated, name, capability) {
+			return nil, framework.NewStatus(framework.Unschedulable, fmt.Sprintf("queue %s is overused on %s", queue.Name, name))
+		}
+	}
+
+	return nil, nil
+}
+
+func (v *Volcano) PreFilterExtensions() framework.PreFilterExtensions {
+	return nil
+}
+
+// Reserve assumes pod in the cache so that it's counted by its PodGroup and Queue while waiting for other members.
+func (v *Volcano) Reserve(ctx context.Context, state *framework.CycleState, pod *corev1.Pod, nodeName string) *framework.Status {
+	v.cache.AssumePod(pod)
+	return nil
+}
+
+func (v *Volcano) Unreserve(ctx context.Context, state *framework.CycleState, pod *corev1.Pod, nodeName string) {
+	v.cache.ForgetPod(pod)
+}
+
+func (v *Volcano) Permit(ctx context.Context, state *framework.CycleState, pod *corev1.Pod, nodeName string) (*framework.Status, time.Duration) {
+	pg, _ := v.cache.PodGroupOf(pod)
+	if pg == nil || pg.MinMember <= 1 {
+		return nil, 0
+	}
+
+	// pods bound or waiting for other members are assumed, including pod itself which is reserved
+	members := v.cache.Members(pg)
+	if members < int(pg.MinMember) {
+		klog.V(4).InfoS("Wait for other members of podgroup", "pod", klog.KObj(pod), "podgroup", pg.Namespace+"/"+pg.Name, "members", members)
+		return framework.NewStatus(framework.Wait), v.waitTime
+	}
+
+	v.handle.IterateOverWaitingPods(func(waitingPod framework.WaitingPod) {
+		if p := waitingPod.GetPod(); p.Namespace == pg.Namespace && GroupName(p) == pg.Name {
+			waitingPod.Allow(v.Name())
+		}
+	})
+
+	return nil, 0
+}
+
+func addResource(source *framework.Resource, res *framework.Resource) {
+	source.MilliCPU += res.MilliCPU
+	source.Memory += res.Memory
+	source.EphemeralStorage += res.EphemeralStorage
+	for name, quantity := range res.ScalarResources {
+		source.AddScalar(name, quantity)
+	}
+}
+
+func subResource(source *framework.Resource, res *framework.Resource) {
+	source.MilliCPU -= res.MilliCPU
+	source.Memory -= res.Memory
+	source.EphemeralStorage -= res.EphemeralStorage
+	for name, quantity := range res.ScalarResources {
+		source.AddScalar(name, -quantity)
+	}
+}
+
+func exceeds(allocated *framework.Resource, name corev1.ResourceName, capability resource.Quantity) bool {
+	switch name {
+	case corev1.ResourceCPU:
+		return allocated.MilliCPU > capability.MilliValue()
+	case corev1.ResourceMemory:
+		return allocated.Memory > capability.Value()
+	case corev1.ResourceEphemeralStorage:
+		return allocated.EphemeralStorage > capability.Value()
+	default:
+		return allocated.ScalarResources[name] > capability.Value()
+	}
+}
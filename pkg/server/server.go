@@ -0,0 +1,303 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2"
+
+	"github.com/k-cloud-labs/kluster-capacity/pkg/simulator"
+	"github.com/k-cloud-labs/kluster-capacity/pkg/simulator/capacityestimation"
+	"github.com/k-cloud-labs/kluster-capacity/pkg/simulator/clustercompression"
+	"github.com/k-cloud-labs/kluster-capacity/pkg/simulator/schedulersimulation"
+)
+
+const (
+	CapacityEstimationPath  = "/api/v1/capacityestimation"
+	ClusterCompressionPath  = "/api/v1/clustercompression"
+	SchedulerSimulationPath = "/api/v1/schedulersimulation"
+	HealthzPath             = "/healthz"
+)
+
+// CapacityEstimationRequest is the body of the request to CapacityEstimationPath.
+type CapacityEstimationRequest struct {
+	// pod templates to estimate
+	Pods []*corev1.Pod `json:"pods"`
+	// max number of replicas of each template, the limit of the server is used if zero
+	MaxLimit         int      `json:"maxLimit,omitempty"`
+	ExcludeNodes     []string `json:"excludeNodes,omitempty"`
+	EnablePreemption bool     `json:"enablePreemption,omitempty"`
+}
+
+// ClusterCompressionRequest is the body of the request to ClusterCompressionPath.
+type ClusterCompressionRequest struct {
+	// max number of nodes to scale down, the limit of the server is used if zero
+	MaxLimit          int                                  `json:"maxLimit,omitempty"`
+	ExcludeNodes      []string                             `json:"excludeNodes,omitempty"`
+	FilterNodeOptions clustercompression.FilterNodeOptions `json:"filterNodeOptions"`
+	// label of nodes to compress and report them group by group
+	GroupByLabel string `json:"groupByLabel,omitempty"`
+	// rules to order the candidate nodes
+	NodeOrderOptions clustercompression.NodeOrderOptions `json:"nodeOrderOptions"`
+}
+
+// SchedulerSimulationRequest is the body of the request to SchedulerSimulationPath.
+type SchedulerSimulationRequest struct {
+	ExcludeNodes []string `json:"excludeNodes,omitempty"`
+	// one of AllScheduled|AllSucceed, AllSucceed if empty
+	ExitCondition string `json:"exitCondition,omitempty"`
+}
+
+// Server serves ce, cc and ss over HTTP. the world is loaded once and cached between requests, it's refreshed
+// on each interval so that requests are simulated against a recent state of the cluster.
+type Server struct {
+	loader simulator.Loader
+	// template of the options of each simulation, InitObjs is replaced by the cached world
+	options simulator.Options
+	// max duration of each simulation, unlimited if zero
+	timeout time.Duration
+
+	lock        sync.RWMutex
+	objs        []runtime.Object
+	refreshedAt time.Time
+
+	mux *http.ServeMux
+}
+
+// New creates a server which loads the world by loader, options are shared by all simulations.
+func New(loader simulator.Loader, options simulator.Options, timeout time.Duration) *Server {
+	s := &Server{
+		loader:  loader,
+		options: options,
+		timeout: timeout,
+		mux:     http.NewServeMux(),
+	}
+
+	s.mux.HandleFunc(CapacityEstimationPath, s.handleCapacityEstimation)
+	s.mux.HandleFunc(ClusterCompressionPath, s.handleClusterCompression)
+	s.mux.HandleFunc(SchedulerSimulationPath, s.handleSchedulerSimulation)
+	s.mux.HandleFunc(HealthzPath, s.handleHealthz)
+
+	return s
+}
+
+// Refresh reloads the world, the cached one is kept if it fails. requests being simulated are not affected.
+func (s *Server) Refresh() error {
+	objs, err := s.loader()
+	if err != nil {
+		return fmt.Errorf("failed to load the world: %v", err)
+	}
+
+	s.lock.Lock()
+	s.objs = objs
+	s.refreshedAt = time.Now()
+	s.lock.Unlock()
+
+	klog.V(2).InfoS("World refreshed", "objects", len(objs))
+
+	return nil
+}
+
+// Run loads the world, then serves on addr and refreshes the world on each interval until ctx is done.
+// the world is never refreshed if interval is zero.
+func (s *Server) Run(ctx context.Context, addr string, interval time.Duration) error {
+	if err := s.Refresh(); err != nil {
+		return err
+	}
+
+	if interval > 0 {
+		go func() {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					if err := s.Refresh(); err != nil {
+						klog.ErrorS(err, "Failed to refresh the world")
+					}
+				}
+			}
+		}()
+	}
+
+	server := &http.Server{Addr: addr, Handler: s}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	klog.InfoS("Serving", "address", addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) handleCapacityEstimation(w http.ResponseWriter, r *http.Request) {
+	req := &CapacityEstimationRequest{}
+	if !s.decode(w, r, req) {
+		return
+	}
+	if len(req.Pods) == 0 {
+		http.Error(w, "no pods to estimate", http.StatusBadRequest)
+		return
+	}
+
+	options, ok := s.simulatorOptions(w, req.MaxLimit, req.ExcludeNodes)
+	if !ok {
+		return
+	}
+
+	s.simulate(w, r, func(ctx context.Context) (interface{}, error) {
+		return capacityestimation.Estimate(ctx, &capacityestimation.Config{
+			Options:          options,
+			Pods:             req.Pods,
+			EnablePreemption: req.EnablePreemption,
+		})
+	})
+}
+
+func (s *Server) handleClusterCompression(w http.ResponseWriter, r *http.Request) {
+	// same defaults as the flags of cc
+	req := &ClusterCompressionRequest{
+		FilterNodeOptions: clustercompression.FilterNodeOptions{
+			ExcludeNotReadyNode: true,
+			ExcludeTaintNode:    true,
+		},
+	}
+	if !s.decode(w, r, req) {
+		return
+	}
+
+	options, ok := s.simulatorOptions(w, req.MaxLimit, req.ExcludeNodes)
+	if !ok {
+		return
+	}
+
+	s.simulate(w, r, func(ctx context.Context) (interface{}, error) {
+		return clustercompression.Compress(ctx, &clustercompression.Config{
+			Options:           options,
+			FilterNodeOptions: req.FilterNodeOptions,
+			GroupByLabel:      req.GroupByLabel,
+			NodeOrderOptions:  req.NodeOrderOptions,
+		})
+	})
+}
+
+func (s *Server) handleSchedulerSimulation(w http.ResponseWriter, r *http.Request) {
+	req := &SchedulerSimulationRequest{}
+	if !s.decode(w, r, req) {
+		return
+	}
+
+	switch req.ExitCondition {
+	case "":
+		req.ExitCondition = schedulersimulation.ExitWhenAllSucceed
+	case schedulersimulation.ExitWhenAllScheduled, schedulersimulation.ExitWhenAllSucceed:
+	default:
+		http.Error(w, fmt.Sprintf("unsupported exit condition %s, one of: %s|%s", req.ExitCondition,
+			schedulersimulation.ExitWhenAllScheduled, schedulersimulation.ExitWhenAllSucceed), http.StatusBadRequest)
+		return
+	}
+
+	options, ok := s.simulatorOptions(w, 0, req.ExcludeNodes)
+	if !ok {
+		return
+	}
+
+	s.simulate(w, r, func(ctx context.Context) (interface{}, error) {
+		return schedulersimulation.Simulate(ctx, &schedulersimulation.Config{
+			Options:       options,
+			ExitCondition: req.ExitCondition,
+		})
+	})
+}
+
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	s.lock.RLock()
+	loaded, refreshedAt := s.objs != nil, s.refreshedAt
+	s.lock.RUnlock()
+
+	if !loaded {
+		http.Error(w, "world not loaded", http.StatusServiceUnavailable)
+		return
+	}
+
+	_, _ = fmt.Fprintf(w, "ok, world refreshed at %s\n", refreshedAt.Format(time.RFC3339))
+}
+
+// decode decodes the body of the POST request into req, an error is replied if false is returned.
+func (s *Server) decode(w http.ResponseWriter, r *http.Request, req interface{}) bool {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "only POST is allowed", http.StatusMethodNotAllowed)
+		return false
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		http.Error(w, fmt.Sprintf("failed to decode request: %v", err), http.StatusBadRequest)
+		return false
+	}
+
+	return true
+}
+
+// simulatorOptions returns the options of a simulation against the cached world, an error is replied if false
+// is returned.
+func (s *Server) simulatorOptions(w http.ResponseWriter, maxLimit int, excludeNodes []string) (simulator.Options, bool) {
+	s.lock.RLock()
+	objs := s.objs
+	s.lock.RUnlock()
+
+	if objs == nil {
+		http.Error(w, "world not loaded", http.StatusServiceUnavailable)
+		return simulator.Options{}, false
+	}
+
+	options := s.options
+	options.Source = simulator.SourceObjects
+	options.InitObjs = objs
+	if maxLimit > 0 {
+		options.MaxLimit = maxLimit
+	}
+	options.ExcludeNodes = append(append([]string(nil), options.ExcludeNodes...), excludeNodes...)
+
+	return options, true
+}
+
+// simulate runs fn with the timeout of the server and replies its result as JSON.
+func (s *Server) simulate(w http.ResponseWriter, r *http.Request, fn func(ctx context.Context) (interface{}, error)) {
+	ctx := r.Context()
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+
+	result, err := fn(ctx)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		klog.ErrorS(err, "Failed to write response", "path", r.URL.Path)
+	}
+}
@@ -0,0 +1,295 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/k-cloud-labs/kluster-capacity/pkg/simulator"
+	"github.com/k-cloud-labs/kluster-capacity/pkg/simulator/capacityestimation"
+	"github.com/k-cloud-labs/kluster-capacity/pkg/simulator/clustercompression"
+	"github.com/k-cloud-labs/kluster-capacity/pkg/simulator/schedulersimulation"
+)
+
+func TestServerNotLoaded(t *testing.T) {
+	server := httptest.NewServer(New(newTestLoader(2), simulator.Options{MaxLimit: 100}, 0))
+	defer server.Close()
+
+	// the world is not loaded before the first refresh
+	for _, path := range []string{CapacityEstimationPath, ClusterCompressionPath, SchedulerSimulationPath} {
+		if code, body := post(t, server.URL+path, &CapacityEstimationRequest{Pods: []*corev1.Pod{newTestPod("template", "")}}); code != http.StatusServiceUnavailable {
+			t.Errorf("expected %d from %s before refresh, got %d: %s", http.StatusServiceUnavailable, path, code, body)
+		}
+	}
+	if code := get(t, server.URL+HealthzPath); code != http.StatusServiceUnavailable {
+		t.Errorf("expected %d from %s before refresh, got %d", http.StatusServiceUnavailable, HealthzPath, code)
+	}
+}
+
+func TestServerEndpoints(t *testing.T) {
+	s := New(newTestLoader(2), simulator.Options{MaxLimit: 100}, 0)
+	if err := s.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	if code := get(t, server.URL+HealthzPath); code != http.StatusOK {
+		t.Errorf("expected %d from %s, got %d", http.StatusOK, HealthzPath, code)
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		req      interface{}
+		wantCode int
+		// checks the body of a successful response
+		check func(t *testing.T, body []byte)
+	}{
+		{
+			name:     "ce",
+			path:     CapacityEstimationPath,
+			req:      &CapacityEstimationRequest{Pods: []*corev1.Pod{newTestPod("template", "")}},
+			wantCode: http.StatusOK,
+			check: func(t *testing.T, body []byte) {
+				var reviews capacityestimation.CapacityEstimationReviews
+				decode(t, body, &reviews)
+				// 2 nodes of 2 cpu, one of them is taken by the pod bound, the pending pod is not simulated by ce
+				if len(reviews) != 1 || reviews[0].Status.Replicas != 3 {
+					t.Errorf("expected 3 replicas of the template, got %s", body)
+				}
+			},
+		},
+		{
+			name:     "ce with max limit of the request",
+			path:     CapacityEstimationPath,
+			req:      &CapacityEstimationRequest{Pods: []*corev1.Pod{newTestPod("template", "")}, MaxLimit: 1},
+			wantCode: http.StatusOK,
+			check: func(t *testing.T, body []byte) {
+				var reviews capacityestimation.CapacityEstimationReviews
+				decode(t, body, &reviews)
+				if len(reviews) != 1 || reviews[0].Status.Replicas != 1 {
+					t.Errorf("expected 1 replica of the template, got %s", body)
+				}
+			},
+		},
+		{
+			name:     "cc",
+			path:     ClusterCompressionPath,
+			req:      &ClusterCompressionRequest{},
+			wantCode: http.StatusOK,
+			check: func(t *testing.T, body []byte) {
+				review := &clustercompression.ClusterCompressionReview{}
+				decode(t, body, review)
+				if review.Status.StopReason == nil || len(review.Status.Nodes) != 2 {
+					t.Errorf("expected the outcome of 2 nodes, got %s", body)
+				}
+			},
+		},
+		{
+			name:     "ss",
+			path:     SchedulerSimulationPath,
+			req:      &SchedulerSimulationRequest{ExitCondition: schedulersimulation.ExitWhenAllScheduled},
+			wantCode: http.StatusOK,
+			check: func(t *testing.T, body []byte) {
+				review := &schedulersimulation.SchedulerSimulationReview{}
+				decode(t, body, review)
+				if len(review.UnschedulablePods) != 0 || len(review.Details) != 2 {
+					t.Errorf("expected the pending pod scheduled on one of 2 nodes, got %s", body)
+				}
+			},
+		},
+		{
+			name:     "ce without pods",
+			path:     CapacityEstimationPath,
+			req:      &CapacityEstimationRequest{},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "ss with unsupported exit condition",
+			path:     SchedulerSimulationPath,
+			req:      &SchedulerSimulationRequest{ExitCondition: "Never"},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "malformed request",
+			path:     ClusterCompressionPath,
+			req:      "{",
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, body := post(t, server.URL+tt.path, tt.req)
+			if code != tt.wantCode {
+				t.Fatalf("expected %d, got %d: %s", tt.wantCode, code, body)
+			}
+			if tt.check != nil {
+				tt.check(t, body)
+			}
+		})
+	}
+
+	for _, path := range []string{CapacityEstimationPath, ClusterCompressionPath, SchedulerSimulationPath} {
+		if code := get(t, server.URL+path); code != http.StatusMethodNotAllowed {
+			t.Errorf("expected %d from GET %s, got %d", http.StatusMethodNotAllowed, path, code)
+		}
+	}
+}
+
+func TestServerRefresh(t *testing.T) {
+	numNodes := 1
+	s := New(func() ([]runtime.Object, error) {
+		return newTestLoader(numNodes)()
+	}, simulator.Options{MaxLimit: 100}, 0)
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	replicas := func() int32 {
+		if err := s.Refresh(); err != nil {
+			t.Fatal(err)
+		}
+		code, body := post(t, server.URL+CapacityEstimationPath, &CapacityEstimationRequest{Pods: []*corev1.Pod{newTestPod("template", "")}})
+		if code != http.StatusOK {
+			t.Fatalf("expected %d, got %d: %s", http.StatusOK, code, body)
+		}
+		var reviews capacityestimation.CapacityEstimationReviews
+		decode(t, body, &reviews)
+		return reviews[0].Status.Replicas
+	}
+
+	if got := replicas(); got != 1 {
+		t.Errorf("expected 1 replica in the world of 1 node, got %d", got)
+	}
+
+	// the world is swapped by refresh
+	numNodes = 3
+	if got := replicas(); got != 5 {
+		t.Errorf("expected 5 replicas in the world of 3 nodes, got %d", got)
+	}
+
+	// the cached world is kept if refresh fails
+	s.loader = func() ([]runtime.Object, error) {
+		return nil, fmt.Errorf("unavailable")
+	}
+	if err := s.Refresh(); err == nil {
+		t.Fatal("expected refresh failed")
+	}
+	if code := get(t, server.URL+HealthzPath); code != http.StatusOK {
+		t.Errorf("expected %d from %s after refresh failed, got %d", http.StatusOK, HealthzPath, code)
+	}
+}
+
+// newTestLoader loads nodes of 2 cpu, a pod of 1 cpu is bound to the first node and another one is pending.
+func newTestLoader(numNodes int) simulator.Loader {
+	return func() ([]runtime.Object, error) {
+		objs := []runtime.Object{
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: metav1.NamespaceDefault}},
+			newTestPod("bound", "node-0"),
+			newTestPod("pending", ""),
+		}
+
+		allocatable := corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("2"),
+			corev1.ResourceMemory: resource.MustParse("4Gi"),
+			corev1.ResourcePods:   resource.MustParse("110"),
+		}
+		for i := 0; i < numNodes; i++ {
+			name := fmt.Sprintf("node-%d", i)
+			objs = append(objs, &corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{corev1.LabelHostname: name}},
+				Status: corev1.NodeStatus{
+					Capacity:    allocatable,
+					Allocatable: allocatable,
+					Conditions:  []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+				},
+			})
+		}
+
+		return objs, nil
+	}
+}
+
+func newTestPod(name, nodeName string) *corev1.Pod {
+	controller := true
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       metav1.NamespaceDefault,
+			UID:             types.UID("uid-" + name),
+			OwnerReferences: []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "rs", UID: "rs", Controller: &controller}},
+		},
+		Spec: corev1.PodSpec{
+			NodeName:      nodeName,
+			SchedulerName: corev1.DefaultSchedulerName,
+			Containers: []corev1.Container{{
+				Name:  "app",
+				Image: "nginx",
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("1"),
+						corev1.ResourceMemory: resource.MustParse("1Gi"),
+					},
+				},
+			}},
+		},
+	}
+}
+
+func post(t *testing.T, url string, req interface{}) (int, []byte) {
+	t.Helper()
+
+	var body []byte
+	if raw, ok := req.(string); ok {
+		body = []byte(raw)
+	} else {
+		var err error
+		if body, err = json.Marshal(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	resp, err := http.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	buf := &bytes.Buffer{}
+	if _, err := buf.ReadFrom(resp.Body); err != nil {
+		t.Fatal(err)
+	}
+
+	return resp.StatusCode, buf.Bytes()
+}
+
+func get(t *testing.T, url string) int {
+	t.Helper()
+
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	return resp.StatusCode
+}
+
+func decode(t *testing.T, body []byte, v interface{}) {
+	t.Helper()
+
+	if err := json.NewDecoder(strings.NewReader(string(body))).Decode(v); err != nil {
+		t.Fatalf("failed to decode %s: %v", body, err)
+	}
+}
@@ -3,6 +3,7 @@ package capacityestimation
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -37,6 +38,10 @@ type CapacityEstimationReviewStatus struct {
 	StopReason *CapacityEstimationReviewScheduleStopReason `json:"stopReason"`
 	// per node information about the scheduling simulation
 	Pods []*CapacityEstimationReviewResult `json:"pods"`
+	// namespace/name of pods preempted which are not attributed to any replica, only when preemption is enabled
+	UnattributedVictims []string `json:"unattributedVictims,omitempty"`
+	// problems which may affect the result
+	Warnings []string `json:"warnings,omitempty"`
 }
 
 type CapacityEstimationReviewResult struct {
@@ -45,6 +50,16 @@ type CapacityEstimationReviewResult struct {
 	ReplicasOnNodes []*ReplicasOnNode `json:"replicasOnNodes"`
 	// reason why no more pods could schedule (if any on this node)
 	Summary []StopReasonSummary `json:"summary"`
+	// pods preempted by each replica, only when preemption is enabled
+	Preemptions []*Preemption `json:"preemptions,omitempty"`
+}
+
+type Preemption struct {
+	// name of the replica preempting victims
+	Replica  string `json:"replica"`
+	NodeName string `json:"nodeName"`
+	// namespace/name of victims grouped by PriorityClass
+	Victims map[string][]string `json:"victims"`
 }
 
 type ReplicasOnNode struct {
@@ -122,6 +137,9 @@ func (r CapacityEstimationReviews) Print(verbose bool, format string) error {
 
 	if format == "" && !verbose {
 		fmt.Println(t.Render())
+		if len(r) > 0 {
+			utils.PrintWarnings(r[0].Status.Warnings)
+		}
 	}
 
 	return nil
@@ -157,6 +175,10 @@ func parsePodsReview(templatePods []*corev1.Pod, status *pkg.Status) []*Capacity
 	}
 
 	for i, pod := range status.PodsForEstimation {
+		if victims, ok := status.Victims[pod.Namespace+"/"+pod.Name]; ok {
+			result[i%templatesCount].Preemptions = append(result[i%templatesCount].Preemptions, getPreemption(pod, victims))
+		}
+
 		nodeName := pod.Spec.NodeName
 		first := true
 		for _, sum := range result[i%templatesCount].ReplicasOnNodes {
@@ -181,6 +203,23 @@ func parsePodsReview(templatePods []*corev1.Pod, status *pkg.Status) []*Capacity
 	return result
 }
 
+func getPreemption(pod *corev1.Pod, victims []*corev1.Pod) *Preemption {
+	preemption := &Preemption{
+		Replica:  pod.Name,
+		NodeName: pod.Spec.NodeName,
+		Victims:  make(map[string][]string),
+	}
+	for _, victim := range victims {
+		priorityClass := victim.Spec.PriorityClassName
+		if len(priorityClass) == 0 {
+			priorityClass = "<none>"
+		}
+		preemption.Victims[priorityClass] = append(preemption.Victims[priorityClass], victim.Namespace+"/"+victim.Name)
+	}
+
+	return preemption
+}
+
 func getReviewSpec(podTemplates []*corev1.Pod) CapacityEstimationReviewSpec {
 	podCopies := make([]corev1.Pod, len(podTemplates))
 	deepCopyPods(podTemplates, podCopies)
@@ -192,11 +231,22 @@ func getReviewSpec(podTemplates []*corev1.Pod) CapacityEstimationReviewSpec {
 
 func getReviewStatus(pods []*corev1.Pod, status *pkg.Status) CapacityEstimationReviewStatus {
 	return CapacityEstimationReviewStatus{
-		CreationTimestamp: time.Now(),
-		Replicas:          int32(len(status.PodsForEstimation)),
-		StopReason:        getMainStopReason(status.StopReason),
-		Pods:              parsePodsReview(pods, status),
+		CreationTimestamp:   time.Now(),
+		Replicas:            int32(len(status.PodsForEstimation)),
+		StopReason:          getMainStopReason(status.StopReason),
+		Pods:                parsePodsReview(pods, status),
+		UnattributedVictims: podKeys(status.UnattributedVictims),
+		Warnings:            status.Warnings,
+	}
+}
+
+func podKeys(pods []*corev1.Pod) []string {
+	var keys []string
+	for _, pod := range pods {
+		keys = append(keys, pod.Namespace+"/"+pod.Name)
 	}
+
+	return keys
 }
 
 func deepCopyPods(in []*corev1.Pod, out []corev1.Pod) {
@@ -253,6 +303,7 @@ func capacityEstimationReviewPrettyPrint(r *CapacityEstimationReview, verbose bo
 
 	if verbose {
 		fmt.Printf("\nTermination reason: %v: %v\n", r.Status.StopReason.StopType, r.Status.StopReason.StopMessage)
+		utils.PrintWarnings(r.Status.Warnings)
 	}
 
 	if verbose && r.Status.Replicas > 0 {
@@ -272,5 +323,27 @@ func capacityEstimationReviewPrettyPrint(r *CapacityEstimationReview, verbose bo
 				fmt.Printf("\t- %v: %v instance(s)\n", ron.NodeName, ron.Replicas)
 			}
 		}
+
+		for _, pod := range r.Status.Pods {
+			if len(pod.Preemptions) == 0 {
+				continue
+			}
+			fmt.Printf("\nPods preempted by %v:\n", pod.PodName)
+			for _, preemption := range pod.Preemptions {
+				fmt.Printf("\t- %v on %v:\n", preemption.Replica, preemption.NodeName)
+				priorityClasses := make([]string, 0, len(preemption.Victims))
+				for priorityClass := range preemption.Victims {
+					priorityClasses = append(priorityClasses, priorityClass)
+				}
+				sort.Strings(priorityClasses)
+				for _, priorityClass := range priorityClasses {
+					fmt.Printf("\t\t%v: %v\n", priorityClass, strings.Join(preemption.Victims[priorityClass], ", "))
+				}
+			}
+		}
+
+		if len(r.Status.UnattributedVictims) > 0 {
+			fmt.Printf("\nPods preempted by no replica bound: %v\n", strings.Join(r.Status.UnattributedVictims, ", "))
+		}
 	}
 }
@@ -1,7 +1,9 @@
 package capacityestimation
 
 import (
+	"context"
 	"fmt"
+	"sync/atomic"
 
 	"golang.org/x/sync/errgroup"
 	corev1 "k8s.io/api/core/v1"
@@ -11,12 +13,43 @@ import (
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
 
-	"github.com/k-cloud-labs/kluster-capacity/app/cmds/capacityestimation/options"
 	"github.com/k-cloud-labs/kluster-capacity/pkg"
 	pkgframework "github.com/k-cloud-labs/kluster-capacity/pkg/framework"
+	pkgsimulator "github.com/k-cloud-labs/kluster-capacity/pkg/simulator"
 	"github.com/k-cloud-labs/kluster-capacity/pkg/utils"
 )
 
+// Config is the config of ce.
+type Config struct {
+	pkgsimulator.Options
+	// pod templates to estimate, each of them is simulated separately in the same world
+	Pods []*corev1.Pod
+	// preempt lower priority pods when the pod could not be scheduled in free space
+	EnablePreemption bool
+	// max number of pod templates simulated at the same time, unlimited if not positive
+	Parallelism int
+	// world shared by the simulators, a new one is used if nil. it's set by callers analyzing the world ce starts from
+	World *pkgframework.World
+}
+
+// Estimate estimates the number of replicas of each pod template could be scheduled.
+func Estimate(ctx context.Context, conf *Config) (CapacityEstimationReviews, error) {
+	s, err := NewCESimulatorExecutor(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Initialize(conf.InitObjs...); err != nil {
+		return nil, err
+	}
+
+	if err := s.Run(ctx); err != nil {
+		return nil, err
+	}
+
+	return s.Report().(CapacityEstimationReviews), nil
+}
+
 type PodGenerator interface {
 	Generate() *corev1.Pod
 }
@@ -29,23 +62,28 @@ type simulator struct {
 	simulatedPod *corev1.Pod
 	maxSimulated int
 	simulated    int
+	// pods of the gang being simulated which are not bound yet
+	pendingPods int32
+	// the simulation is deterministic if not zero
+	seed int64
 }
 
 type multiSimulator struct {
 	simulators []*simulator
 	reports    pkg.Printer
+	// max number of simulators running at the same time, unlimited if not positive
+	parallelism int
 }
 
 // NewCESimulatorExecutor create a ce simulator which is completely independent of apiserver so no need
-// for kubeconfig nor for apiserver url
-func NewCESimulatorExecutor(conf *options.CapacityEstimationConfig) (pkg.Simulator, error) {
+// for kubeconfig nor for apiserver url. all simulators share the same world and only store their own changes.
+func NewCESimulatorExecutor(conf *Config) (pkg.Simulator, error) {
+	world := conf.World
+	if world == nil {
+		world = pkgframework.NewWorld()
+	}
 	newSimulator := func(pod *corev1.Pod) (*simulator, error) {
-		kubeSchedulerConfig, err := utils.BuildKubeSchedulerCompletedConfig(conf.Options.SchedulerConfig, conf.Options.KubeConfig)
-		if err != nil {
-			return nil, err
-		}
-
-		kubeConfig, err := utils.BuildRestConfig(conf.Options.KubeConfig)
+		kubeSchedulerConfig, kubeConfig, err := conf.BuildConfigs()
 		if err != nil {
 			return nil, err
 		}
@@ -54,7 +92,8 @@ func NewCESimulatorExecutor(conf *options.CapacityEstimationConfig) (pkg.Simulat
 			podGenerator: NewSinglePodGenerator(pod),
 			simulatedPod: pod,
 			simulated:    0,
-			maxSimulated: conf.Options.MaxLimit,
+			maxSimulated: conf.MaxLimit,
+			seed:         conf.Seed,
 		}
 
 		err = s.addEventHandlers(kubeSchedulerConfig.InformerFactory)
@@ -62,8 +101,9 @@ func NewCESimulatorExecutor(conf *options.CapacityEstimationConfig) (pkg.Simulat
 			return nil, err
 		}
 
-		framework, err := pkgframework.NewKubeSchedulerFramework(kubeSchedulerConfig, kubeConfig,
-			pkgframework.WithExcludeNodes(conf.Options.ExcludeNodes),
+		framework, err := conf.NewFramework(kubeSchedulerConfig, kubeConfig,
+			pkgframework.WithWorld(world),
+			pkgframework.WithPreemption(conf.EnablePreemption),
 			pkgframework.WithPostBindHook(s.postBindHook))
 		if err != nil {
 			return nil, err
@@ -75,7 +115,8 @@ func NewCESimulatorExecutor(conf *options.CapacityEstimationConfig) (pkg.Simulat
 	}
 
 	ms := &multiSimulator{
-		simulators: make([]*simulator, 0),
+		simulators:  make([]*simulator, 0),
+		parallelism: conf.Parallelism,
 	}
 
 	for _, pod := range conf.Pods {
@@ -90,14 +131,19 @@ func NewCESimulatorExecutor(conf *options.CapacityEstimationConfig) (pkg.Simulat
 	return ms, nil
 }
 
-func (s *simulator) Run(func() error) error {
-	return s.Framework.Run(s.createNextPod)
+func (s *simulator) Run(ctx context.Context) error {
+	return s.Framework.Run(ctx, s.createNextPod)
 }
 
 func (s *simulator) Report() pkg.Printer {
-	return generateReport([]*corev1.Pod{s.simulatedPod}, s.Status())
+	review := generateReport([]*corev1.Pod{s.simulatedPod}, s.Status())
+	review.Status.CreationTimestamp = utils.ReportTimestamp(s.seed)
+
+	return review
 }
 
+// Initialize initializes all simulators, the shared world is only initialized by the first one, the others
+// only initialize the state of their own frameworks, e.g. the cache of volcano.
 func (ms *multiSimulator) Initialize(objs ...runtime.Object) error {
 	for _, s := range ms.simulators {
 		if err := s.Initialize(objs...); err != nil {
@@ -108,14 +154,17 @@ func (ms *multiSimulator) Initialize(objs ...runtime.Object) error {
 	return nil
 }
 
-func (ms *multiSimulator) Run() error {
+func (ms *multiSimulator) Run(ctx context.Context) error {
 	g := errgroup.Group{}
+	if ms.parallelism > 0 {
+		g.SetLimit(ms.parallelism)
+	}
 	reports := make(CapacityEstimationReviews, len(ms.simulators))
 	for i, s := range ms.simulators {
 		i := i
 		s := s
 		g.Go(func() error {
-			err := s.Run(nil)
+			err := s.Run(ctx)
 			if err != nil {
 				return err
 			}
@@ -141,6 +190,11 @@ func (ms *multiSimulator) Report() pkg.Printer {
 func (s *simulator) postBindHook(bindPod *corev1.Pod) error {
 	s.UpdateEstimationPods(bindPod)
 
+	// pods of a gang are bound concurrently, create next pods after the whole gang is bound
+	if atomic.AddInt32(&s.pendingPods, -1) > 0 {
+		return nil
+	}
+
 	if s.maxSimulated > 0 && s.simulated >= s.maxSimulated {
 		return s.Stop(fmt.Sprintf("LimitReached: Maximum number of pods simulated: %v", s.maxSimulated))
 	}
@@ -152,19 +206,31 @@ func (s *simulator) postBindHook(bindPod *corev1.Pod) error {
 }
 
 func (s *simulator) createNextPod() error {
-	pod := s.podGenerator.Generate()
-	s.simulated++
-	klog.V(2).InfoS("create simulate pod", "count", s.simulated, "key", pod.Namespace+"/"+pod.Name)
+	var pods []*corev1.Pod
+	if gf, ok := s.Framework.(pkg.GangFramework); ok {
+		pods = gf.GeneratePodGang(s.podGenerator.Generate)
+	} else {
+		pods = []*corev1.Pod{s.podGenerator.Generate()}
+	}
 
-	return s.CreatePod(pod)
+	atomic.StoreInt32(&s.pendingPods, int32(len(pods)))
+	for _, pod := range pods {
+		s.simulated++
+		klog.V(2).InfoS("create simulate pod", "count", s.simulated, "key", pod.Namespace+"/"+pod.Name)
+
+		if err := s.CreatePod(pod); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 func (s *simulator) addEventHandlers(informerFactory informers.SharedInformerFactory) (err error) {
 	_, _ = informerFactory.Core().V1().Pods().Informer().AddEventHandler(
 		cache.FilteringResourceEventHandler{
 			FilterFunc: func(obj interface{}) bool {
-				if pod, ok := obj.(*corev1.Pod); ok && pod.Spec.SchedulerName == pkg.SchedulerName &&
-					metav1.HasAnnotation(pod.ObjectMeta, pkg.PodProvisioner) {
+				if pod, ok := obj.(*corev1.Pod); ok && metav1.HasAnnotation(pod.ObjectMeta, pkg.PodProvisioner) {
 					return true
 				}
 				return false
@@ -173,9 +239,9 @@ func (s *simulator) addEventHandlers(informerFactory informers.SharedInformerFac
 				UpdateFunc: func(oldObj, newObj interface{}) {
 					if pod, ok := newObj.(*corev1.Pod); ok {
 						for _, podCondition := range pod.Status.Conditions {
-							// Only for pending pods provisioned by ce
+							// Only for pending pods provisioned by ce, pods nominated by preemption are going to be scheduled
 							if podCondition.Type == corev1.PodScheduled && podCondition.Status == corev1.ConditionFalse &&
-								podCondition.Reason == corev1.PodReasonUnschedulable {
+								podCondition.Reason == corev1.PodReasonUnschedulable && len(pod.Status.NominatedNodeName) == 0 {
 								err = s.Stop(fmt.Sprintf("%v: %v", podCondition.Reason, podCondition.Message))
 							}
 						}
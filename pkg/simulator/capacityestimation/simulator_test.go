@@ -0,0 +1,167 @@
+package capacityestimation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	pkgsimulator "github.com/k-cloud-labs/kluster-capacity/pkg/simulator"
+)
+
+// no score plugin is enabled, so every node is scored the same
+const noScoreSchedulerConfig = `apiVersion: kubescheduler.config.k8s.io/v1
+kind: KubeSchedulerConfiguration
+profiles:
+- schedulerName: default-scheduler
+  plugins:
+    score:
+      disabled:
+      - name: "*"
+`
+
+func TestEstimateWithSeedIsDeterministic(t *testing.T) {
+	noScoreConfig := filepath.Join(t.TempDir(), "scheduler.yaml")
+	if err := os.WriteFile(noScoreConfig, []byte(noScoreSchedulerConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name            string
+		schedulerConfig string
+		// nodes are filled up with pods of low priority if true
+		full       bool
+		preemption bool
+	}{
+		{
+			name: "ties of node scores",
+		},
+		{
+			name:            "no score plugin",
+			schedulerConfig: noScoreConfig,
+		},
+		{
+			name:       "ties of preemption candidates",
+			full:       true,
+			preemption: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var first []byte
+			for i := 0; i < 3; i++ {
+				reviews, err := Estimate(context.TODO(), &Config{
+					Options: pkgsimulator.Options{
+						Source:          pkgsimulator.SourceObjects,
+						InitObjs:        newTestWorld(8, tt.full),
+						SchedulerConfig: tt.schedulerConfig,
+						MaxLimit:        4,
+						Seed:            42,
+					},
+					Pods:             []*corev1.Pod{newTestPod("template", "", 1000)},
+					EnablePreemption: tt.preemption,
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				report, err := json.Marshal(reviews)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if i == 0 {
+					first = report
+					continue
+				}
+				if !bytes.Equal(first, report) {
+					t.Fatalf("expected identical reports with the same seed, got\n%s\nand\n%s", first, report)
+				}
+			}
+		})
+	}
+}
+
+// newTestWorld returns identical nodes, which are full of identical pods of low priority if full is true.
+func newTestWorld(numNodes int, full bool) []runtime.Object {
+	objs := []runtime.Object{
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: metav1.NamespaceDefault}},
+		&schedulingv1.PriorityClass{ObjectMeta: metav1.ObjectMeta{Name: "low"}, Value: 100},
+		&schedulingv1.PriorityClass{ObjectMeta: metav1.ObjectMeta{Name: "high"}, Value: 1000},
+	}
+
+	allocatable := corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("2"),
+		corev1.ResourceMemory: resource.MustParse("4Gi"),
+		corev1.ResourcePods:   resource.MustParse("110"),
+	}
+	for i := 0; i < numNodes; i++ {
+		name := fmt.Sprintf("node-%d", i)
+		objs = append(objs, &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{corev1.LabelHostname: name}},
+			Status: corev1.NodeStatus{
+				Capacity:    allocatable,
+				Allocatable: allocatable,
+				Conditions:  []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+			},
+		})
+		if full {
+			for j := 0; j < 2; j++ {
+				objs = append(objs, newTestPod(fmt.Sprintf("pod-%d-%d", i, j), name, 100))
+			}
+		}
+	}
+
+	return objs
+}
+
+func newTestPod(name, nodeName string, priority int32) *corev1.Pod {
+	priorityClassName := "low"
+	if priority > 100 {
+		priorityClassName = "high"
+	}
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: metav1.NamespaceDefault,
+			UID:       types.UID("uid-" + name),
+		},
+		Spec: corev1.PodSpec{
+			NodeName:          nodeName,
+			SchedulerName:     corev1.DefaultSchedulerName,
+			PriorityClassName: priorityClassName,
+			Priority:          &priority,
+			Containers: []corev1.Container{{
+				Name:  "app",
+				Image: "nginx",
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("1"),
+						corev1.ResourceMemory: resource.MustParse("1Gi"),
+					},
+				},
+			}},
+		},
+	}
+	if len(nodeName) > 0 {
+		pod.OwnerReferences = []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "rs", UID: "rs", Controller: boolPtr(true)}}
+		pod.Status.Phase = corev1.PodRunning
+	}
+
+	return pod
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
@@ -3,14 +3,13 @@ package clustercompression
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	clientset "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/util/workqueue"
-
-	"github.com/k-cloud-labs/kluster-capacity/app/cmds/clustercompression/options"
 )
 
 const (
@@ -18,11 +17,16 @@ const (
 	NodeScaledDownSuccessLabel = "kc.k-cloud-labs.io/node-scale-down-success"
 	KubernetesMasterNodeLabel  = "node-role.kubernetes.io/master"
 	NodeScaleDownDisableLabel  = "kc.k-cloud-labs.io/scale-down-disabled"
+	NodeScaleDownBlockedLabel  = "kc.k-cloud-labs.io/node-scale-down-blocked"
 )
 
 type NodeFilter interface {
 	SelectNode() *Status
 	Done()
+	// BlockingPods returns the pods blocking nodes to be scaled down found by the last selection
+	BlockingPods() []*BlockingPod
+	// Excluded returns the statuses of nodes excluded by the last selection keyed by the node name
+	Excluded() map[string]*FilterStatus
 }
 
 func defaultFilterFunc() FilterFunc {
@@ -44,6 +48,14 @@ func defaultFilterFunc() FilterFunc {
 				}
 			}
 
+			_, ok = node.Labels[NodeScaleDownBlockedLabel]
+			if ok {
+				return &FilterStatus{
+					Success:   false,
+					ErrReason: ErrReasonBlockedByPDB,
+				}
+			}
+
 			_, ok = node.Labels[NodeScaledDownSuccessLabel]
 			if ok {
 				return &FilterStatus{
@@ -65,11 +77,29 @@ func defaultFilterFunc() FilterFunc {
 }
 
 type singleNodeFilter struct {
-	clientset      clientset.Interface
-	nodeFilter     FilterFunc
+	clientset  clientset.Interface
+	nodeFilter FilterFunc
+	// candidates are selected group by group if not empty
+	groupByLabel string
+	// candidates are tried in the order they are listed if nil
+	orderer        NodeOrderer
 	selectedCount  int
 	candidateNode  []*corev1.Node
 	candidateIndex int
+	// statuses of excluded nodes, updated each time the nodes are listed
+	excluded map[string]*FilterStatus
+}
+
+// FilterNodeOptions are the rules to select the nodes to scale down.
+type FilterNodeOptions struct {
+	ExcludeNotReadyNode bool `json:"excludeNotReadyNode"`
+	ExcludeTaintNode    bool `json:"excludeTaintNode"`
+	IgnoreStaticPod     bool `json:"ignoreStaticPod"`
+	IgnoreMirrorPod     bool `json:"ignoreMirrorPod"`
+	IgnoreCloneSet      bool `json:"ignoreCloneSet"`
+	IgnoreVolumePod     bool `json:"ignoreVolumePod"`
+	// applies the same rules as cluster-autoscaler, e.g. safe-to-evict annotations and pods without controller
+	ClusterAutoscalerCompatible bool `json:"clusterAutoscalerCompatible"`
 }
 
 type Status struct {
@@ -77,7 +107,7 @@ type Status struct {
 	ErrReason string
 }
 
-func NewNodeFilter(client clientset.Interface, getPodsByNode PodsByNodeFunc, excludeNodes []string, filterNodeOptions options.FilterNodeOptions) (NodeFilter, error) {
+func NewNodeFilter(client clientset.Interface, getPodsByNode PodsByNodeFunc, hasDisruptionBudget PodMatchFunc, excludeNodes []string, filterNodeOptions FilterNodeOptions, groupByLabel string, orderer NodeOrderer) (NodeFilter, error) {
 	excludeNodeMap := make(map[string]bool)
 	for i := range excludeNodes {
 		excludeNodeMap[excludeNodes[i]] = true
@@ -92,17 +122,23 @@ func NewNodeFilter(client clientset.Interface, getPodsByNode PodsByNodeFunc, exc
 		WithIgnoreCloneSet(filterNodeOptions.IgnoreCloneSet).
 		WithIgnoreMirrorPod(filterNodeOptions.IgnoreMirrorPod).
 		WithIgnoreVolumePod(filterNodeOptions.IgnoreVolumePod).
+		WithClusterAutoscalerCompatible(filterNodeOptions.ClusterAutoscalerCompatible).
 		WithPodsByNodeFunc(getPodsByNode).
+		WithDisruptionBudgetFunc(hasDisruptionBudget).
 		BuildFilterFunc()
 
 	return &singleNodeFilter{
-		clientset:  client,
-		nodeFilter: nodeFilter,
+		clientset:    client,
+		nodeFilter:   nodeFilter,
+		groupByLabel: groupByLabel,
+		orderer:      orderer,
 	}, nil
 }
 
 func (g *singleNodeFilter) SelectNode() *Status {
 	if len(g.candidateNode) != 0 && g.candidateIndex <= len(g.candidateNode)-1 {
+		// pods rescheduled from the nodes scaled down change the scores of the rest
+		g.order(g.candidateNode[g.candidateIndex:])
 		selectNode := g.candidateNode[g.candidateIndex]
 		g.candidateIndex++
 		if g.candidateIndex == len(g.candidateNode) {
@@ -135,10 +171,12 @@ func (g *singleNodeFilter) SelectNode() *Status {
 		}
 	})
 
+	g.excluded = make(map[string]*FilterStatus)
 	for i := 0; i < len(nodes.Items); i++ {
 		switch result[i].(type) {
 		case *FilterStatus:
 			statuses = append(statuses, result[i].(*FilterStatus))
+			g.excluded[nodes.Items[i].Name] = result[i].(*FilterStatus)
 		case *corev1.Node:
 			g.candidateNode = append(g.candidateNode, result[i].(*corev1.Node))
 		}
@@ -148,15 +186,52 @@ func (g *singleNodeFilter) SelectNode() *Status {
 		return convertFilterStatusesToStatus(statuses, g.selectedCount)
 	}
 
+	g.order(g.candidateNode)
 	g.candidateIndex++
 
 	return &Status{Node: g.candidateNode[0]}
 }
 
+// order sorts the candidates by the orderer and then by the group.
+func (g *singleNodeFilter) order(nodes []*corev1.Node) {
+	if g.orderer != nil {
+		g.orderer.Order(nodes)
+	}
+
+	// the order is kept within each group, nodes without the label are selected first since they are grouped by
+	// the empty value
+	if len(g.groupByLabel) > 0 {
+		sort.SliceStable(nodes, func(i, j int) bool {
+			return nodes[i].Labels[g.groupByLabel] < nodes[j].Labels[g.groupByLabel]
+		})
+	}
+}
+
 func (g *singleNodeFilter) Done() {
 	g.selectedCount++
 }
 
+func (g *singleNodeFilter) BlockingPods() []*BlockingPod {
+	nodeNames := make([]string, 0, len(g.excluded))
+	for nodeName, status := range g.excluded {
+		if len(status.BlockingPods) > 0 {
+			nodeNames = append(nodeNames, nodeName)
+		}
+	}
+	sort.Strings(nodeNames)
+
+	var result []*BlockingPod
+	for _, nodeName := range nodeNames {
+		result = append(result, g.excluded[nodeName].BlockingPods...)
+	}
+
+	return result
+}
+
+func (g *singleNodeFilter) Excluded() map[string]*FilterStatus {
+	return g.excluded
+}
+
 func convertFilterStatusesToStatus(statuses []*FilterStatus, selectedCount int) *Status {
 	statusMap := make(map[string]int)
 
@@ -174,9 +249,15 @@ func convertFilterStatusesToStatus(statuses []*FilterStatus, selectedCount int)
 		}
 	}
 
+	reasons := make([]string, 0, len(statusMap))
+	for reason := range statusMap {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+
 	sb := strings.Builder{}
-	for reason, count := range statusMap {
-		_, _ = sb.WriteString(fmt.Sprintf("%d %s; ", count, reason))
+	for _, reason := range reasons {
+		_, _ = sb.WriteString(fmt.Sprintf("%d %s; ", statusMap[reason], reason))
 	}
 
 	return &Status{ErrReason: sb.String()}
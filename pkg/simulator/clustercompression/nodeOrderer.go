@@ -0,0 +1,154 @@
+package clustercompression
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/k-cloud-labs/kluster-capacity/pkg/utils"
+)
+
+const (
+	// NodeOrderLeastRequested tries nodes with the lowest average ratio of requested cpu and memory first
+	NodeOrderLeastRequested = "least-requested"
+	// NodeOrderFewestPods tries nodes with the fewest pods to reschedule first
+	NodeOrderFewestPods = "fewest-pods"
+	// NodeOrderMostExpensive tries nodes with the highest price first
+	NodeOrderMostExpensive = "most-expensive"
+	// NodeOrderRandom tries nodes in random order, it's deterministic with the seed
+	NodeOrderRandom = "random"
+
+	DefaultPriceLabel = "node.kubernetes.io/instance-type"
+)
+
+// NodeOrderer decides which candidate nodes are tried first, so that more nodes could be scaled down or the nodes
+// are cheaper to drain.
+type NodeOrderer interface {
+	// Order sorts the candidate nodes in place, nodes in the front are tried first. it's called before each node is
+	// selected with the candidates not tried yet, since their pods change as nodes are scaled down.
+	Order(nodes []*corev1.Node)
+}
+
+// NodeOrderOptions are the rules to order the candidate nodes.
+type NodeOrderOptions struct {
+	// one of least-requested|fewest-pods|most-expensive|random, nodes are tried in the order they are listed if empty
+	Order string `json:"order,omitempty"`
+	// price of nodes keyed by the value of PriceLabel or the node name, only used by most-expensive
+	Prices map[string]float64 `json:"prices,omitempty"`
+	// label of nodes to look up the price, node.kubernetes.io/instance-type if empty
+	PriceLabel string `json:"priceLabel,omitempty"`
+}
+
+// NodeOrders returns the built-in orders.
+func NodeOrders() []string {
+	return []string{NodeOrderLeastRequested, NodeOrderFewestPods, NodeOrderMostExpensive, NodeOrderRandom}
+}
+
+// NewNodeOrderer returns the built-in orderer of options, it's nil if no order is specified. seed makes random order
+// deterministic if not zero.
+func NewNodeOrderer(options NodeOrderOptions, getPodsByNode PodsByNodeFunc, seed int64) (NodeOrderer, error) {
+	switch options.Order {
+	case "":
+		return nil, nil
+	case NodeOrderLeastRequested:
+		return &scoreNodeOrderer{score: leastRequestedScore(getPodsByNode)}, nil
+	case NodeOrderFewestPods:
+		return &scoreNodeOrderer{score: fewestPodsScore(getPodsByNode)}, nil
+	case NodeOrderMostExpensive:
+		priceLabel := options.PriceLabel
+		if len(priceLabel) == 0 {
+			priceLabel = DefaultPriceLabel
+		}
+		return &scoreNodeOrderer{score: mostExpensiveScore(options.Prices, priceLabel)}, nil
+	case NodeOrderRandom:
+		if seed == 0 {
+			seed = time.Now().UnixNano()
+		}
+		return &randomNodeOrderer{rand: rand.New(rand.NewSource(seed))}, nil
+	default:
+		return nil, fmt.Errorf("unsupported node order %s, one of: %v", options.Order, NodeOrders())
+	}
+}
+
+// scoreNodeOrderer tries nodes with lower scores first, nodes with the same score keep their order.
+type scoreNodeOrderer struct {
+	score func(node *corev1.Node) float64
+}
+
+func (o *scoreNodeOrderer) Order(nodes []*corev1.Node) {
+	scores := make(map[string]float64, len(nodes))
+	for _, node := range nodes {
+		scores[node.Name] = o.score(node)
+	}
+
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return scores[nodes[i].Name] < scores[nodes[j].Name]
+	})
+}
+
+type randomNodeOrderer struct {
+	rand *rand.Rand
+}
+
+func (o *randomNodeOrderer) Order(nodes []*corev1.Node) {
+	o.rand.Shuffle(len(nodes), func(i, j int) {
+		nodes[i], nodes[j] = nodes[j], nodes[i]
+	})
+}
+
+func leastRequestedScore(getPodsByNode PodsByNodeFunc) func(node *corev1.Node) float64 {
+	return func(node *corev1.Node) float64 {
+		var milliCPU, memory int64
+		// no pods on the node is not an error
+		pods, _ := getPodsByNode(node.Name)
+		for _, pod := range pods {
+			if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+				continue
+			}
+			request := utils.ComputePodResourceRequest(pod)
+			milliCPU += request.MilliCPU
+			memory += request.Memory
+		}
+
+		var score float64
+		if allocatable := node.Status.Allocatable.Cpu().MilliValue(); allocatable > 0 {
+			score += float64(milliCPU) / float64(allocatable)
+		}
+		if allocatable := node.Status.Allocatable.Memory().Value(); allocatable > 0 {
+			score += float64(memory) / float64(allocatable)
+		}
+
+		return score / 2
+	}
+}
+
+// fewestPodsScore counts the pods rescheduled when the node is scaled down, i.e. daemonset pods are not counted.
+func fewestPodsScore(getPodsByNode PodsByNodeFunc) func(node *corev1.Node) float64 {
+	return func(node *corev1.Node) float64 {
+		var count int
+		pods, _ := getPodsByNode(node.Name)
+		for _, pod := range pods {
+			if !utils.IsDaemonsetPod(pod.OwnerReferences) && pod.DeletionTimestamp == nil {
+				count++
+			}
+		}
+
+		return float64(count)
+	}
+}
+
+// mostExpensiveScore looks up the price by the label first and then the node name, nodes without price are tried last.
+func mostExpensiveScore(prices map[string]float64, priceLabel string) func(node *corev1.Node) float64 {
+	return func(node *corev1.Node) float64 {
+		if value, ok := node.Labels[priceLabel]; ok {
+			if price, ok := prices[value]; ok {
+				return -price
+			}
+		}
+
+		return -prices[node.Name]
+	}
+}
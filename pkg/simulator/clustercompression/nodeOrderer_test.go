@@ -0,0 +1,258 @@
+package clustercompression
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestNodeOrderer(t *testing.T) {
+	now := metav1.Now()
+	pods := map[string][]*corev1.Pod{
+		// 3 cpu and 4Gi of 4 cpu and 8Gi requested, 1 pod rescheduled
+		"node-a": {
+			newOrderTestPod("1", "1Gi", "ReplicaSet", nil),
+			newOrderTestPod("2", "3Gi", "DaemonSet", nil),
+		},
+		// 1 cpu and 2Gi requested, 2 pods rescheduled
+		"node-b": {
+			newOrderTestPod("500m", "1Gi", "ReplicaSet", nil),
+			newOrderTestPod("500m", "1Gi", "ReplicaSet", nil),
+		},
+		// 2 cpu and 4Gi requested, 1 pod rescheduled
+		"node-c": {
+			newOrderTestPod("1", "2Gi", "ReplicaSet", nil),
+			newOrderTestPod("1", "2Gi", "ReplicaSet", &now),
+		},
+		// nothing requested
+		"node-d": nil,
+	}
+	getPodsByNode := func(name string) ([]*corev1.Pod, error) {
+		return pods[name], nil
+	}
+
+	tests := []struct {
+		name    string
+		options NodeOrderOptions
+		nodes   []*corev1.Node
+		want    []string
+	}{
+		{
+			name:    "least requested",
+			options: NodeOrderOptions{Order: NodeOrderLeastRequested},
+			nodes:   newOrderTestNodes("node-a", "node-b", "node-c", "node-d"),
+			want:    []string{"node-d", "node-b", "node-c", "node-a"},
+		},
+		{
+			name:    "fewest pods without daemonset and terminating pods",
+			options: NodeOrderOptions{Order: NodeOrderFewestPods},
+			nodes:   newOrderTestNodes("node-b", "node-a", "node-c", "node-d"),
+			want:    []string{"node-d", "node-a", "node-c", "node-b"},
+		},
+		{
+			name:    "ties keep the order",
+			options: NodeOrderOptions{Order: NodeOrderFewestPods},
+			nodes:   newOrderTestNodes("node-c", "node-a"),
+			want:    []string{"node-c", "node-a"},
+		},
+		{
+			name: "most expensive by label and name",
+			options: NodeOrderOptions{
+				Order:  NodeOrderMostExpensive,
+				Prices: map[string]float64{"large": 2, "small": 1, "node-c": 3},
+			},
+			nodes: []*corev1.Node{
+				newOrderTestNode("node-a", map[string]string{DefaultPriceLabel: "small"}),
+				newOrderTestNode("node-b", map[string]string{DefaultPriceLabel: "large"}),
+				newOrderTestNode("node-c", nil),
+			},
+			want: []string{"node-c", "node-b", "node-a"},
+		},
+		{
+			name: "most expensive by custom label",
+			options: NodeOrderOptions{
+				Order:      NodeOrderMostExpensive,
+				Prices:     map[string]float64{"spot": 1, "on-demand": 2},
+				PriceLabel: "billing",
+			},
+			nodes: []*corev1.Node{
+				newOrderTestNode("node-a", map[string]string{"billing": "spot", DefaultPriceLabel: "on-demand"}),
+				newOrderTestNode("node-b", map[string]string{"billing": "on-demand"}),
+			},
+			want: []string{"node-b", "node-a"},
+		},
+		{
+			name: "nodes without price last",
+			options: NodeOrderOptions{
+				Order:  NodeOrderMostExpensive,
+				Prices: map[string]float64{"small": 1},
+			},
+			nodes: []*corev1.Node{
+				newOrderTestNode("node-a", nil),
+				newOrderTestNode("node-b", map[string]string{DefaultPriceLabel: "unknown"}),
+				newOrderTestNode("node-c", map[string]string{DefaultPriceLabel: "small"}),
+			},
+			want: []string{"node-c", "node-a", "node-b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orderer, err := NewNodeOrderer(tt.options, getPodsByNode, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			orderer.Order(tt.nodes)
+			if got := nodeNames(tt.nodes); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRandomNodeOrderer(t *testing.T) {
+	names := []string{"node-0", "node-1", "node-2", "node-3", "node-4", "node-5", "node-6", "node-7"}
+
+	order := func(seed int64) []string {
+		orderer, err := NewNodeOrderer(NodeOrderOptions{Order: NodeOrderRandom}, nil, seed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		nodes := newOrderTestNodes(names...)
+		orderer.Order(nodes)
+		return nodeNames(nodes)
+	}
+
+	first := order(42)
+	if got := order(42); !reflect.DeepEqual(got, first) {
+		t.Errorf("expected the same order %v with the same seed, got %v", first, got)
+	}
+	if reflect.DeepEqual(first, names) {
+		t.Errorf("expected the nodes shuffled, got %v", first)
+	}
+}
+
+func TestNewNodeOrderer(t *testing.T) {
+	orderer, err := NewNodeOrderer(NodeOrderOptions{}, nil, 0)
+	if err != nil || orderer != nil {
+		t.Errorf("expected no orderer without order, got %v, %v", orderer, err)
+	}
+
+	if _, err := NewNodeOrderer(NodeOrderOptions{Order: "cheapest"}, nil, 0); err == nil {
+		t.Error("expected an unsupported order rejected")
+	}
+}
+
+func TestSelectNodeReordersCandidates(t *testing.T) {
+	nodes := newOrderTestNodes("node-a", "node-b", "node-c")
+	client := fake.NewSimpleClientset()
+	for _, node := range nodes {
+		if err := client.Tracker().Add(node); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pods := map[string][]*corev1.Pod{
+		"node-b": {newOrderTestPod("1", "1Gi", "ReplicaSet", nil)},
+		"node-c": {newOrderTestPod("1", "1Gi", "ReplicaSet", nil), newOrderTestPod("1", "1Gi", "ReplicaSet", nil)},
+	}
+	getPodsByNode := func(name string) ([]*corev1.Pod, error) {
+		return pods[name], nil
+	}
+	orderer, err := NewNodeOrderer(NodeOrderOptions{Order: NodeOrderFewestPods}, getPodsByNode, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filter, err := NewNodeFilter(client, getPodsByNode, func(*corev1.Pod) bool { return false }, nil, FilterNodeOptions{}, "", orderer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := selectedNodeName(filter.SelectNode()); got != "node-a" {
+		t.Fatalf("expected node-a without pods selected first, got %s", got)
+	}
+
+	// the pods of node-b are rescheduled to node-a, so node-c has fewer pods now
+	pods["node-b"] = append(pods["node-b"], newOrderTestPod("1", "1Gi", "ReplicaSet", nil), newOrderTestPod("1", "1Gi", "ReplicaSet", nil))
+	if got := selectedNodeName(filter.SelectNode()); got != "node-c" {
+		t.Fatalf("expected node-c with fewer pods selected next, got %s", got)
+	}
+	if got := selectedNodeName(filter.SelectNode()); got != "node-b" {
+		t.Fatalf("expected node-b selected last, got %s", got)
+	}
+}
+
+// selectedNodeName returns the name of the selected node or the reason why no node is selected.
+func selectedNodeName(status *Status) string {
+	if status == nil {
+		return "nothing"
+	}
+	if status.Node == nil {
+		return status.ErrReason
+	}
+
+	return status.Node.Name
+}
+
+func newOrderTestNodes(names ...string) []*corev1.Node {
+	nodes := make([]*corev1.Node, 0, len(names))
+	for _, name := range names {
+		nodes = append(nodes, newOrderTestNode(name, nil))
+	}
+
+	return nodes
+}
+
+func newOrderTestNode(name string, labels map[string]string) *corev1.Node {
+	allocatable := corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("4"),
+		corev1.ResourceMemory: resource.MustParse("8Gi"),
+	}
+
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+		Status:     corev1.NodeStatus{Capacity: allocatable, Allocatable: allocatable},
+	}
+}
+
+var orderTestPodIndex int
+
+func newOrderTestPod(cpu, memory, ownerKind string, deletionTimestamp *metav1.Time) *corev1.Pod {
+	orderTestPodIndex++
+	controller := true
+
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              fmt.Sprintf("pod-%d", orderTestPodIndex),
+			Namespace:         metav1.NamespaceDefault,
+			OwnerReferences:   []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: ownerKind, Name: "owner", Controller: &controller}},
+			DeletionTimestamp: deletionTimestamp,
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name: "app",
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse(cpu),
+						corev1.ResourceMemory: resource.MustParse(memory),
+					},
+				},
+			}},
+		},
+	}
+}
+
+func nodeNames(nodes []*corev1.Node) []string {
+	names := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		names = append(names, node.Name)
+	}
+
+	return names
+}
@@ -1,33 +1,65 @@
 package clustercompression
 
 import (
+	"strings"
+
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/k-cloud-labs/kluster-capacity/pkg/utils"
 )
 
 const (
-	ErrReasonFailedScaleDown   = "node(s) can't be scale down because of insufficient resource in other nodes"
-	ErrReasonSuccessScaleDown  = "node(s) has been successfully scale down"
-	ErrReasonScaleDownDisabled = "node(s) have label with scale down disabled"
-	ErrReasonMasterNode        = "master node(s)"
-	ErrReasonTaintNode         = "node(s) have taint"
-	ErrReasonNotReadyNode      = "not ready node(s)"
-	ErrReasonStaticPod         = "node(s) have static pod"
-	ErrReasonMirrorPod         = "node(s) have mirror pod"
-	ErrReasonCloneset          = "node(s) have inplace update pod"
-	ErrReasonVolumePod         = "node(s) have pod used hostpath"
-	ErrReasonUnknown           = "node(s) have unknown error"
+	ErrReasonFailedScaleDown     = "node(s) can't be scale down because of insufficient resource in other nodes"
+	ErrReasonSuccessScaleDown    = "node(s) has been successfully scale down"
+	ErrReasonScaleDownDisabled   = "node(s) have label with scale down disabled"
+	ErrReasonMasterNode          = "master node(s)"
+	ErrReasonTaintNode           = "node(s) have taint"
+	ErrReasonNotReadyNode        = "not ready node(s)"
+	ErrReasonStaticPod           = "node(s) have static pod"
+	ErrReasonMirrorPod           = "node(s) have mirror pod"
+	ErrReasonCloneset            = "node(s) have inplace update pod"
+	ErrReasonVolumePod           = "node(s) have pod used hostpath"
+	ErrReasonBlockedByPDB        = "node(s) blocked by PodDisruptionBudget"
+	ErrReasonUnknown             = "node(s) have unknown error"
+	ErrReasonCAScaleDownDisabled = "node(s) have annotation with scale down disabled by cluster-autoscaler"
+	ErrReasonCABlockingPod       = "node(s) have pod blocking scale down by cluster-autoscaler"
+)
+
+const (
+	// annotations respected by cluster-autoscaler
+	CAScaleDownDisabledAnnotation = "cluster-autoscaler.kubernetes.io/scale-down-disabled"
+	CASafeToEvictAnnotation       = "cluster-autoscaler.kubernetes.io/safe-to-evict"
+	// comma separated names of the local volumes which don't block the pod to be evicted
+	CASafeToEvictLocalVolumesAnnotation = "cluster-autoscaler.kubernetes.io/safe-to-evict-local-volumes"
+
+	BlockingReasonNotSafeToEvict  = "annotated with " + CASafeToEvictAnnotation + "=false"
+	BlockingReasonNotReplicated   = "not replicated by a controller"
+	BlockingReasonKubeSystemNoPDB = "in kube-system without PodDisruptionBudget"
+	BlockingReasonLocalStorage    = "using local storage"
 )
 
 // FilterFunc is a filter for a node.
 type FilterFunc func(*corev1.Node) *FilterStatus
 type PodsByNodeFunc func(name string) ([]*corev1.Pod, error)
 
+// PodMatchFunc tells whether a pod is matched, e.g. by any PodDisruptionBudget.
+type PodMatchFunc func(pod *corev1.Pod) bool
+
 type FilterStatus struct {
 	// true means that node is able to simulate
 	Success   bool
 	ErrReason string
+	// pods blocking the node to be scaled down in cluster-autoscaler compatible mode
+	BlockingPods []*BlockingPod
+}
+
+// BlockingPod is a pod which cluster-autoscaler refuses to evict, so the node it's on is never scaled down.
+type BlockingPod struct {
+	NodeName string `json:"nodeName"`
+	// namespace/name of the pod
+	Pod    string `json:"pod"`
+	Reason string `json:"reason"`
 }
 
 type Options struct {
@@ -40,6 +72,11 @@ type Options struct {
 	ignoreMirrorPod     bool
 	ignoreCloneSet      bool
 	ignoreVolumePod     bool
+	// applies the same rules as cluster-autoscaler to decide whether a node could be scaled down
+	clusterAutoscalerCompatible bool
+	// tells whether a pod is covered by a PodDisruptionBudget, pods in kube-system without one block the node in
+	// cluster-autoscaler compatible mode
+	hasDisruptionBudget PodMatchFunc
 }
 
 // NewOptions returns an empty Options.
@@ -95,11 +132,23 @@ func (o *Options) WithIgnoreVolumePod(ignoreVolumePod bool) *Options {
 	return o
 }
 
+// WithClusterAutoscalerCompatible set clusterAutoscalerCompatible options
+func (o *Options) WithClusterAutoscalerCompatible(clusterAutoscalerCompatible bool) *Options {
+	o.clusterAutoscalerCompatible = clusterAutoscalerCompatible
+	return o
+}
+
 func (o *Options) WithPodsByNodeFunc(podsByNodeFunc PodsByNodeFunc) *Options {
 	o.getPodsByNode = podsByNodeFunc
 	return o
 }
 
+// WithDisruptionBudgetFunc set the func telling whether a pod is covered by a PodDisruptionBudget
+func (o *Options) WithDisruptionBudgetFunc(hasDisruptionBudget PodMatchFunc) *Options {
+	o.hasDisruptionBudget = hasDisruptionBudget
+	return o
+}
+
 // BuildFilterFunc builds a final FilterFunc based on Options.
 func (o *Options) BuildFilterFunc() FilterFunc {
 	return func(node *corev1.Node) *FilterStatus {
@@ -123,6 +172,13 @@ func (o *Options) BuildFilterFunc() FilterFunc {
 			}
 		}
 
+		if o.clusterAutoscalerCompatible && node.Annotations[CAScaleDownDisabledAnnotation] == "true" {
+			return &FilterStatus{
+				Success:   false,
+				ErrReason: ErrReasonCAScaleDownDisabled,
+			}
+		}
+
 		podList, err := o.getPodsByNode(node.Name)
 		if err != nil {
 			return &FilterStatus{
@@ -131,6 +187,7 @@ func (o *Options) BuildFilterFunc() FilterFunc {
 			}
 		}
 
+		var blockingPods []*BlockingPod
 		for i := range podList {
 			if o.ignoreStaticPod && utils.IsStaticPod(podList[i]) {
 				return &FilterStatus{
@@ -160,11 +217,79 @@ func (o *Options) BuildFilterFunc() FilterFunc {
 				}
 			}
 
+			if o.clusterAutoscalerCompatible {
+				if reason := clusterAutoscalerBlockingReason(podList[i], o.hasDisruptionBudget); len(reason) > 0 {
+					blockingPods = append(blockingPods, &BlockingPod{
+						NodeName: node.Name,
+						Pod:      podList[i].Namespace + "/" + podList[i].Name,
+						Reason:   reason,
+					})
+				}
+			}
 		}
+
+		// all blocking pods are reported instead of the first one
+		if len(blockingPods) > 0 {
+			return &FilterStatus{
+				Success:      false,
+				ErrReason:    ErrReasonCABlockingPod,
+				BlockingPods: blockingPods,
+			}
+		}
+
 		return &FilterStatus{Success: true}
 	}
 }
 
+// clusterAutoscalerBlockingReason returns why cluster-autoscaler refuses to evict the pod with its default flags,
+// it's empty if the pod could be evicted.
+func clusterAutoscalerBlockingReason(pod *corev1.Pod, hasDisruptionBudget PodMatchFunc) string {
+	if utils.IsDaemonsetPod(pod.OwnerReferences) || utils.IsMirrorPod(pod) || utils.IsPodTerminating(pod) ||
+		pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+		return ""
+	}
+
+	switch pod.Annotations[CASafeToEvictAnnotation] {
+	case "true":
+		return ""
+	case "false":
+		return BlockingReasonNotSafeToEvict
+	}
+
+	if metav1.GetControllerOf(pod) == nil {
+		return BlockingReasonNotReplicated
+	}
+
+	// --skip-nodes-with-system-pods is true by default
+	if pod.Namespace == metav1.NamespaceSystem && (hasDisruptionBudget == nil || !hasDisruptionBudget(pod)) {
+		return BlockingReasonKubeSystemNoPDB
+	}
+
+	if hasBlockingLocalStorage(pod) {
+		return BlockingReasonLocalStorage
+	}
+
+	return ""
+}
+
+// hasBlockingLocalStorage returns true if the pod uses hostPath or emptyDir volumes not backed by memory, except the
+// ones listed in the safe-to-evict-local-volumes annotation.
+func hasBlockingLocalStorage(pod *corev1.Pod) bool {
+	safeToEvict := make(map[string]bool)
+	for _, name := range strings.Split(pod.Annotations[CASafeToEvictLocalVolumesAnnotation], ",") {
+		safeToEvict[strings.TrimSpace(name)] = true
+	}
+
+	for _, volume := range pod.Spec.Volumes {
+		local := volume.HostPath != nil || (volume.EmptyDir != nil && volume.EmptyDir.Medium != corev1.StorageMediumMemory)
+		if local && !safeToEvict[volume.Name] {
+			return true
+		}
+	}
+
+	return false
+}
+
 func haveNodeTaint(node *corev1.Node) bool {
 	return len(node.Spec.Taints) != 0
 }
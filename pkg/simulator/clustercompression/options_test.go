@@ -0,0 +1,230 @@
+package clustercompression
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestClusterAutoscalerBlockingReason(t *testing.T) {
+	now := metav1.Now()
+
+	tests := []struct {
+		name string
+		pod  *corev1.Pod
+		// pods in kube-system covered by a PodDisruptionBudget
+		covered bool
+		want    string
+	}{
+		{
+			name: "replicated",
+			pod:  newCATestPod(metav1.NamespaceDefault, "ReplicaSet"),
+		},
+		{
+			name: "not replicated",
+			pod:  newCATestPod(metav1.NamespaceDefault, ""),
+			want: BlockingReasonNotReplicated,
+		},
+		{
+			name: "not replicated but safe to evict",
+			pod:  withAnnotations(newCATestPod(metav1.NamespaceDefault, ""), map[string]string{CASafeToEvictAnnotation: "true"}),
+		},
+		{
+			name: "replicated but not safe to evict",
+			pod:  withAnnotations(newCATestPod(metav1.NamespaceDefault, "ReplicaSet"), map[string]string{CASafeToEvictAnnotation: "false"}),
+			want: BlockingReasonNotSafeToEvict,
+		},
+		{
+			name: "not safe to evict daemonset pod",
+			pod:  withAnnotations(newCATestPod(metav1.NamespaceDefault, "DaemonSet"), map[string]string{CASafeToEvictAnnotation: "false"}),
+		},
+		{
+			name: "mirror pod",
+			pod:  withAnnotations(newCATestPod(metav1.NamespaceDefault, ""), map[string]string{corev1.MirrorPodAnnotationKey: "mirror"}),
+		},
+		{
+			name: "terminating pod",
+			pod: func() *corev1.Pod {
+				pod := newCATestPod(metav1.NamespaceDefault, "")
+				pod.DeletionTimestamp = &now
+				return pod
+			}(),
+		},
+		{
+			name: "succeeded pod",
+			pod: func() *corev1.Pod {
+				pod := newCATestPod(metav1.NamespaceDefault, "")
+				pod.Status.Phase = corev1.PodSucceeded
+				return pod
+			}(),
+		},
+		{
+			name: "kube-system without PodDisruptionBudget",
+			pod:  newCATestPod(metav1.NamespaceSystem, "ReplicaSet"),
+			want: BlockingReasonKubeSystemNoPDB,
+		},
+		{
+			name:    "kube-system with PodDisruptionBudget",
+			pod:     newCATestPod(metav1.NamespaceSystem, "ReplicaSet"),
+			covered: true,
+		},
+		{
+			name: "kube-system without PodDisruptionBudget but safe to evict",
+			pod:  withAnnotations(newCATestPod(metav1.NamespaceSystem, "ReplicaSet"), map[string]string{CASafeToEvictAnnotation: "true"}),
+		},
+		{
+			name: "local storage",
+			pod:  withVolumes(newCATestPod(metav1.NamespaceDefault, "ReplicaSet"), emptyDirVolume("cache", corev1.StorageMediumDefault)),
+			want: BlockingReasonLocalStorage,
+		},
+		{
+			name: "local storage but safe to evict",
+			pod: withAnnotations(withVolumes(newCATestPod(metav1.NamespaceDefault, "ReplicaSet"), emptyDirVolume("cache", corev1.StorageMediumDefault)),
+				map[string]string{CASafeToEvictAnnotation: "true"}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hasDisruptionBudget := func(*corev1.Pod) bool { return tt.covered }
+			if got := clusterAutoscalerBlockingReason(tt.pod, hasDisruptionBudget); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestHasBlockingLocalStorage(t *testing.T) {
+	tests := []struct {
+		name    string
+		volumes []corev1.Volume
+		// value of the safe-to-evict-local-volumes annotation
+		safeToEvict string
+		want        bool
+	}{
+		{
+			name: "no volumes",
+		},
+		{
+			name: "emptyDir on disk",
+			volumes: []corev1.Volume{
+				emptyDirVolume("cache", corev1.StorageMediumDefault),
+			},
+			want: true,
+		},
+		{
+			name: "emptyDir backed by memory",
+			volumes: []corev1.Volume{
+				emptyDirVolume("cache", corev1.StorageMediumMemory),
+			},
+		},
+		{
+			name: "hostPath",
+			volumes: []corev1.Volume{
+				{Name: "logs", VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/var/log"}}},
+			},
+			want: true,
+		},
+		{
+			name: "configMap",
+			volumes: []corev1.Volume{
+				{Name: "config", VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{}}},
+			},
+		},
+		{
+			name: "all local volumes safe to evict with spaces",
+			volumes: []corev1.Volume{
+				emptyDirVolume("cache", corev1.StorageMediumDefault),
+				{Name: "logs", VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/var/log"}}},
+			},
+			safeToEvict: "cache, logs",
+		},
+		{
+			name: "some local volumes not safe to evict",
+			volumes: []corev1.Volume{
+				emptyDirVolume("cache", corev1.StorageMediumDefault),
+				emptyDirVolume("data", corev1.StorageMediumDefault),
+			},
+			safeToEvict: " cache ",
+			want:        true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := withVolumes(newCATestPod(metav1.NamespaceDefault, "ReplicaSet"), tt.volumes...)
+			if len(tt.safeToEvict) > 0 {
+				pod = withAnnotations(pod, map[string]string{CASafeToEvictLocalVolumesAnnotation: tt.safeToEvict})
+			}
+
+			if got := hasBlockingLocalStorage(pod); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestFilterReportsAllBlockingPods(t *testing.T) {
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-0"}}
+	pods := []*corev1.Pod{
+		newCATestPod(metav1.NamespaceDefault, ""),
+		newCATestPod(metav1.NamespaceDefault, "ReplicaSet"),
+		newCATestPod(metav1.NamespaceSystem, "ReplicaSet"),
+	}
+	for i, name := range []string{"bare", "web", "dns"} {
+		pods[i].Name = name
+	}
+
+	filter := NewOptions().
+		WithClusterAutoscalerCompatible(true).
+		WithPodsByNodeFunc(func(string) ([]*corev1.Pod, error) { return pods, nil }).
+		WithDisruptionBudgetFunc(func(*corev1.Pod) bool { return false }).
+		BuildFilterFunc()
+
+	status := filter(node)
+	if status.Success || status.ErrReason != ErrReasonCABlockingPod {
+		t.Fatalf("expected node blocked by pods, got %+v", status)
+	}
+	want := []*BlockingPod{
+		{NodeName: "node-0", Pod: "default/bare", Reason: BlockingReasonNotReplicated},
+		{NodeName: "node-0", Pod: "kube-system/dns", Reason: BlockingReasonKubeSystemNoPDB},
+	}
+	if !reflect.DeepEqual(status.BlockingPods, want) {
+		t.Errorf("expected %v, got %v", want, status.BlockingPods)
+	}
+
+	// the annotation on the node disables the scale down before pods are checked
+	node.Annotations = map[string]string{CAScaleDownDisabledAnnotation: "true"}
+	if status := filter(node); status.Success || status.ErrReason != ErrReasonCAScaleDownDisabled {
+		t.Errorf("expected scale down disabled, got %+v", status)
+	}
+}
+
+func newCATestPod(namespace, ownerKind string) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: namespace},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	if len(ownerKind) > 0 {
+		controller := true
+		pod.OwnerReferences = []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: ownerKind, Name: "owner", Controller: &controller}}
+	}
+
+	return pod
+}
+
+func withAnnotations(pod *corev1.Pod, annotations map[string]string) *corev1.Pod {
+	pod.Annotations = annotations
+	return pod
+}
+
+func withVolumes(pod *corev1.Pod, volumes ...corev1.Volume) *corev1.Pod {
+	pod.Spec.Volumes = volumes
+	return pod
+}
+
+func emptyDirVolume(name string, medium corev1.StorageMedium) corev1.Volume {
+	return corev1.Volume{Name: name, VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{Medium: medium}}}
+}
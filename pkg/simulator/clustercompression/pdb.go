@@ -0,0 +1,155 @@
+package clustercompression
+
+import (
+	"context"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+	apipod "k8s.io/kubernetes/pkg/api/v1/pod"
+)
+
+// disruptionBudget tracks the disruptions of a PodDisruptionBudget caused by the nodes scaled down.
+type disruptionBudget struct {
+	key       string
+	namespace string
+	selector  labels.Selector
+	// disruptions allowed before any node is scaled down
+	allowed int32
+	// pods evicted from the nodes scaled down
+	disrupted int32
+}
+
+func (b *disruptionBudget) matches(pod *corev1.Pod) bool {
+	return pod.Namespace == b.namespace && b.selector.Matches(labels.Set(pod.Labels))
+}
+
+// disruptionBudgets checks whether the pods on a node could be evicted within the PodDisruptionBudgets, given the
+// nodes already scaled down. evicted pods are rescheduled to other nodes, so a pod moved twice is disrupted twice.
+type disruptionBudgets struct {
+	budgets []*disruptionBudget
+}
+
+// newDisruptionBudgets loads the PodDisruptionBudgets of the world. the allowed disruptions are taken from the status
+// if it's observed by the disruption controller, otherwise they are computed from the spec and the ready pods, e.g. for
+// PodDisruptionBudgets written by hand into a snapshot.
+func newDisruptionBudgets(client clientset.Interface) (*disruptionBudgets, error) {
+	pdbs, err := client.PolicyV1().PodDisruptionBudgets(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	budgets := &disruptionBudgets{}
+	if len(pdbs.Items) == 0 {
+		return budgets, nil
+	}
+
+	pods, err := client.CoreV1().Pods(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range pdbs.Items {
+		pdb := &pdbs.Items[i]
+		selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+		if err != nil {
+			klog.V(2).InfoS("Skip PodDisruptionBudget with invalid selector", "pdb", pdb.Namespace+"/"+pdb.Name, "err", err)
+			continue
+		}
+
+		budget := &disruptionBudget{
+			key:       pdb.Namespace + "/" + pdb.Name,
+			namespace: pdb.Namespace,
+			selector:  selector,
+		}
+		if pdb.Status.ObservedGeneration > 0 {
+			budget.allowed = pdb.Status.DisruptionsAllowed
+		} else {
+			budget.allowed = allowedDisruptions(pdb, budget, pods.Items)
+		}
+		budgets.budgets = append(budgets.budgets, budget)
+	}
+
+	return budgets, nil
+}
+
+// allowedDisruptions computes the disruptions allowed in the same way as the disruption controller, the expected
+// number of pods is the number of bound pods matched and only the ready ones of them are healthy.
+func allowedDisruptions(pdb *policyv1.PodDisruptionBudget, budget *disruptionBudget, pods []corev1.Pod) int32 {
+	var expected, healthy int
+	for i := range pods {
+		if len(pods[i].Spec.NodeName) > 0 && pods[i].DeletionTimestamp == nil && budget.matches(&pods[i]) {
+			expected++
+			if apipod.IsPodReady(&pods[i]) {
+				healthy++
+			}
+		}
+	}
+
+	desiredHealthy := 0
+	if pdb.Spec.MaxUnavailable != nil {
+		maxUnavailable, err := intstr.GetScaledValueFromIntOrPercent(pdb.Spec.MaxUnavailable, expected, true)
+		if err != nil {
+			return 0
+		}
+		desiredHealthy = expected - maxUnavailable
+	} else if pdb.Spec.MinAvailable != nil {
+		minAvailable, err := intstr.GetScaledValueFromIntOrPercent(pdb.Spec.MinAvailable, expected, true)
+		if err != nil {
+			return 0
+		}
+		desiredHealthy = minAvailable
+	}
+
+	if allowed := healthy - desiredHealthy; allowed > 0 {
+		return int32(allowed)
+	}
+
+	return 0
+}
+
+// blocking returns the keys of the PodDisruptionBudgets which would be violated by evicting pods.
+func (d *disruptionBudgets) blocking(pods []*corev1.Pod) []string {
+	var keys []string
+	for _, budget := range d.budgets {
+		var count int32
+		for _, pod := range pods {
+			if budget.matches(pod) {
+				count++
+			}
+		}
+		if count > 0 && budget.disrupted+count > budget.allowed {
+			keys = append(keys, budget.key)
+		}
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// covers returns true if the pod is matched by any PodDisruptionBudget.
+func (d *disruptionBudgets) covers(pod *corev1.Pod) bool {
+	for _, budget := range d.budgets {
+		if budget.matches(pod) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// disrupt records the pods evicted from a node scaled down.
+func (d *disruptionBudgets) disrupt(pods []*corev1.Pod) {
+	for _, budget := range d.budgets {
+		for _, pod := range pods {
+			if budget.matches(pod) {
+				budget.disrupted++
+			}
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package clustercompression
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestAllowedDisruptions(t *testing.T) {
+	now := metav1.Now()
+	// 4 ready pods and 1 pod not ready are expected, the others are not
+	pods := []corev1.Pod{
+		*newPDBTestPod("ready-0", "web", "node-0", true),
+		*newPDBTestPod("ready-1", "web", "node-0", true),
+		*newPDBTestPod("ready-2", "web", "node-1", true),
+		*newPDBTestPod("ready-3", "web", "node-1", true),
+		*newPDBTestPod("not-ready", "web", "node-1", false),
+		*newPDBTestPod("pending", "web", "", false),
+		*newPDBTestPod("other", "db", "node-0", true),
+	}
+	terminating := newPDBTestPod("terminating", "web", "node-0", true)
+	terminating.DeletionTimestamp = &now
+	pods = append(pods, *terminating)
+
+	tests := []struct {
+		name           string
+		minAvailable   *intstr.IntOrString
+		maxUnavailable *intstr.IntOrString
+		pods           []corev1.Pod
+		want           int32
+	}{
+		{
+			name:         "min available",
+			minAvailable: intOrStringPtr(intstr.FromInt(3)),
+			pods:         pods,
+			want:         1,
+		},
+		{
+			name:         "min available more than ready",
+			minAvailable: intOrStringPtr(intstr.FromInt(5)),
+			pods:         pods,
+			want:         0,
+		},
+		{
+			name:         "min available of percent rounded up",
+			minAvailable: intOrStringPtr(intstr.FromString("50%")),
+			pods:         pods,
+			want:         1,
+		},
+		{
+			name:           "max unavailable",
+			maxUnavailable: intOrStringPtr(intstr.FromInt(2)),
+			pods:           pods,
+			want:           1,
+		},
+		{
+			name:           "max unavailable taken by the pod not ready",
+			maxUnavailable: intOrStringPtr(intstr.FromInt(1)),
+			pods:           pods,
+			want:           0,
+		},
+		{
+			name:           "max unavailable of percent rounded up",
+			maxUnavailable: intOrStringPtr(intstr.FromString("50%")),
+			pods:           pods,
+			want:           2,
+		},
+		{
+			name:           "invalid percent",
+			maxUnavailable: intOrStringPtr(intstr.FromString("half")),
+			pods:           pods,
+			want:           0,
+		},
+		{
+			name:         "all pods ready",
+			minAvailable: intOrStringPtr(intstr.FromInt(3)),
+			pods:         pods[:4],
+			want:         1,
+		},
+		{
+			name:         "no pods ready",
+			minAvailable: intOrStringPtr(intstr.FromInt(0)),
+			pods:         pods[4:5],
+			want:         0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pdb := newPDBTestBudget("web", tt.minAvailable, tt.maxUnavailable)
+			budgets, err := newDisruptionBudgets(fake.NewSimpleClientset(pdb, podList(tt.pods)))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(budgets.budgets) != 1 {
+				t.Fatalf("expected 1 budget, got %d", len(budgets.budgets))
+			}
+			if got := budgets.budgets[0].allowed; got != tt.want {
+				t.Errorf("expected %d disruptions allowed, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestAllowedDisruptionsFromStatus(t *testing.T) {
+	// the status observed by the disruption controller is trusted
+	pdb := newPDBTestBudget("web", intOrStringPtr(intstr.FromInt(0)), nil)
+	pdb.Status.ObservedGeneration = 1
+	pdb.Status.DisruptionsAllowed = 3
+
+	budgets, err := newDisruptionBudgets(fake.NewSimpleClientset(pdb, newPDBTestPod("ready", "web", "node-0", true)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := budgets.budgets[0].allowed; got != 3 {
+		t.Errorf("expected 3 disruptions allowed by the status, got %d", got)
+	}
+}
+
+func TestDisruptionBudgetsBlocking(t *testing.T) {
+	web := newPDBTestBudget("web", nil, intOrStringPtr(intstr.FromInt(2)))
+	db := newPDBTestBudget("db", nil, intOrStringPtr(intstr.FromInt(1)))
+	budgets, err := newDisruptionBudgets(fake.NewSimpleClientset(web, db, podList([]corev1.Pod{
+		*newPDBTestPod("web-0", "web", "node-0", true),
+		*newPDBTestPod("web-1", "web", "node-1", true),
+		*newPDBTestPod("web-2", "web", "node-2", true),
+		*newPDBTestPod("db-0", "db", "node-0", true),
+		*newPDBTestPod("db-1", "db", "node-2", true),
+	})))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// web allows 2 disruptions and db allows 1
+	steps := []struct {
+		name string
+		pods []*corev1.Pod
+		// disrupted if not blocked
+		want []string
+	}{
+		{
+			name: "node-0 within both budgets",
+			pods: []*corev1.Pod{newPDBTestPod("web-0", "web", "node-0", true), newPDBTestPod("db-0", "db", "node-0", true)},
+		},
+		{
+			name: "db exhausted",
+			pods: []*corev1.Pod{newPDBTestPod("web-2", "web", "node-2", true), newPDBTestPod("db-1", "db", "node-2", true)},
+			want: []string{"default/db"},
+		},
+		{
+			// web-0 was rescheduled to node-1, so it's disrupted again
+			name: "pod moved twice",
+			pods: []*corev1.Pod{newPDBTestPod("web-0", "web", "node-1", true)},
+		},
+		{
+			name: "web exhausted by the pod moved twice",
+			pods: []*corev1.Pod{newPDBTestPod("web-1", "web", "node-1", true)},
+			want: []string{"default/web"},
+		},
+		{
+			name: "pods not covered",
+			pods: []*corev1.Pod{newPDBTestPod("cache-0", "cache", "node-3", true)},
+		},
+	}
+
+	for _, step := range steps {
+		got := budgets.blocking(step.pods)
+		if !reflect.DeepEqual(got, step.want) {
+			t.Fatalf("%s: expected blocked by %v, got %v", step.name, step.want, got)
+		}
+		if len(got) == 0 {
+			budgets.disrupt(step.pods)
+		}
+	}
+
+	if !budgets.covers(newPDBTestPod("web-3", "web", "", false)) {
+		t.Error("expected the pod covered by web")
+	}
+	if budgets.covers(newPDBTestPod("cache-0", "cache", "node-3", true)) {
+		t.Error("expected the pod not covered")
+	}
+}
+
+func newPDBTestBudget(app string, minAvailable, maxUnavailable *intstr.IntOrString) *policyv1.PodDisruptionBudget {
+	return &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{Name: app, Namespace: metav1.NamespaceDefault},
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			Selector:       &metav1.LabelSelector{MatchLabels: map[string]string{"app": app}},
+			MinAvailable:   minAvailable,
+			MaxUnavailable: maxUnavailable,
+		},
+	}
+}
+
+func newPDBTestPod(name, app, nodeName string, ready bool) *corev1.Pod {
+	status := corev1.ConditionFalse
+	if ready {
+		status = corev1.ConditionTrue
+	}
+
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: metav1.NamespaceDefault, Labels: map[string]string{"app": app}},
+		Spec:       corev1.PodSpec{NodeName: nodeName},
+		Status: corev1.PodStatus{
+			Phase:      corev1.PodRunning,
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: status}},
+		},
+	}
+}
+
+func podList(pods []corev1.Pod) *corev1.PodList {
+	return &corev1.PodList{Items: pods}
+}
+
+func intOrStringPtr(value intstr.IntOrString) *intstr.IntOrString {
+	return &value
+}
@@ -5,6 +5,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/jedib0t/go-pretty/v6/table"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/k-cloud-labs/kluster-capacity/pkg"
@@ -23,6 +24,59 @@ type ClusterCompressionReviewReviewStatus struct {
 	SelectNodeCount      int                                         `json:"SelectNodeCount"`
 	SchedulerCount       int                                         `json:"schedulerCount"`
 	FailedSchedulerCount int                                         `json:"failedSchedulerCount"`
+	Warnings             []string                                    `json:"warnings,omitempty"`
+	// label of nodes to group them, groups are only reported if it's not empty
+	GroupByLabel string             `json:"groupByLabel,omitempty"`
+	Groups       []*NodeGroupReview `json:"groups,omitempty"`
+	// nodes could not be scaled down since evicting their pods violates PodDisruptionBudgets
+	BlockedNodes []*BlockedNode `json:"blockedNodes,omitempty"`
+	// pods blocking nodes to be scaled down in cluster-autoscaler compatible mode
+	BlockingPods []*BlockingPod `json:"blockingPods,omitempty"`
+	// outcome of every node in the order of names
+	Nodes []*NodeReview `json:"nodes,omitempty"`
+}
+
+const (
+	NodeOutcomeScaledDown   = "ScaledDown"
+	NodeOutcomeFailed       = "FailedScaleDown"
+	NodeOutcomeBlocked      = "BlockedByPodDisruptionBudget"
+	NodeOutcomeExcluded     = "Excluded"
+	NodeOutcomeNotSimulated = "NotSimulated"
+	// the node was being drained when the run stopped
+	NodeOutcomeInterrupted = "Interrupted"
+)
+
+// NodeReview explains why a node could or could not be scaled down.
+type NodeReview struct {
+	NodeName string `json:"nodeName"`
+	Outcome  string `json:"outcome"`
+	// reason of the filter excluding the node, only set if excluded
+	FilterReason string `json:"filterReason,omitempty"`
+	// namespace/name of the pod could not be rescheduled and the message of FailedScheduling, only set if failed
+	FailedPod string `json:"failedPod,omitempty"`
+	Message   string `json:"message,omitempty"`
+	// namespace/name of PodDisruptionBudgets blocking the node, only set if blocked
+	PodDisruptionBudgets []string `json:"podDisruptionBudgets,omitempty"`
+	// pods blocking the node in cluster-autoscaler compatible mode
+	BlockingPods []*BlockingPod `json:"blockingPods,omitempty"`
+}
+
+type BlockedNode struct {
+	NodeName string `json:"nodeName"`
+	// namespace/name of PodDisruptionBudgets blocking the node
+	PodDisruptionBudgets []string `json:"podDisruptionBudgets"`
+}
+
+type NodeGroupReview struct {
+	// value of the label, empty for nodes without the label
+	Group              string   `json:"group"`
+	NodeCount          int      `json:"nodeCount"`
+	ScaleDownNodeNames []string `json:"scaleDownNodeNames"`
+	ScaleDownNodeCount int      `json:"scaleDownNodeCount"`
+	RemainingNodeCount int      `json:"remainingNodeCount"`
+	// requests divided by allocatable of the remaining nodes after scaling down
+	CPUUtilization    float64 `json:"cpuUtilization"`
+	MemoryUtilization float64 `json:"memoryUtilization"`
 }
 
 type ClusterCompressionReviewScheduleStopReason struct {
@@ -44,6 +98,7 @@ func getReviewStatus(status *pkg.Status) ClusterCompressionReviewReviewStatus {
 		SelectNodeCount:      status.SelectNodeCount,
 		SchedulerCount:       status.SchedulerCount,
 		FailedSchedulerCount: status.FailedSchedulerCount,
+		Warnings:             status.Warnings,
 	}
 }
 
@@ -79,6 +134,8 @@ func clusterCapacityReviewDefaultPrint(r *ClusterCompressionReview, verbose bool
 			for i := range r.Status.ScaleDownNodeNames {
 				fmt.Printf("\t- %s\n", r.Status.ScaleDownNodeNames[i])
 			}
+			printBlockedNodes(r)
+			printNodes(r)
 		} else {
 			for i := range r.Status.ScaleDownNodeNames {
 				fmt.Println(r.Status.ScaleDownNodeNames[i])
@@ -89,7 +146,79 @@ func clusterCapacityReviewDefaultPrint(r *ClusterCompressionReview, verbose bool
 		fmt.Printf("Scheduled pod %d times, with %d scheduling failure.\n", r.Status.SchedulerCount+r.Status.FailedSchedulerCount, r.Status.FailedSchedulerCount)
 		fmt.Println("No nodes in the cluster can be scaled down.")
 		fmt.Printf("\nTermination reason: %v: %v\n", r.Status.StopReason.StopType, r.Status.StopReason.StopMessage)
+		printBlockedNodes(r)
+		if verbose {
+			printNodes(r)
+		}
 	}
 
+	printGroups(r)
+
+	utils.PrintWarnings(r.Status.Warnings)
+
 	return nil
 }
+
+func printBlockedNodes(r *ClusterCompressionReview) {
+	if len(r.Status.BlockedNodes) > 0 {
+		fmt.Printf("\nnodes blocked by PodDisruptionBudgets:\n")
+		for _, node := range r.Status.BlockedNodes {
+			fmt.Printf("\t- %s: %s\n", node.NodeName, strings.Join(node.PodDisruptionBudgets, ", "))
+		}
+	}
+
+	if len(r.Status.BlockingPods) > 0 {
+		fmt.Printf("\npods blocking nodes to be scaled down:\n")
+		for _, pod := range r.Status.BlockingPods {
+			fmt.Printf("\t- %s on %s: %s\n", pod.Pod, pod.NodeName, pod.Reason)
+		}
+	}
+}
+
+func printNodes(r *ClusterCompressionReview) {
+	if len(r.Status.Nodes) == 0 {
+		return
+	}
+
+	t := table.NewWriter()
+	t.AppendHeader(table.Row{"node", "outcome", "reason"})
+	for _, node := range r.Status.Nodes {
+		t.AppendRow(table.Row{node.NodeName, node.Outcome, nodeReason(node)})
+	}
+	fmt.Printf("\nnodes:\n%s\n", t.Render())
+}
+
+func nodeReason(node *NodeReview) string {
+	switch node.Outcome {
+	case NodeOutcomeFailed:
+		return fmt.Sprintf("pod %s: %s", node.FailedPod, node.Message)
+	case NodeOutcomeBlocked:
+		return strings.Join(node.PodDisruptionBudgets, ", ")
+	case NodeOutcomeExcluded:
+		reason := node.FilterReason
+		for _, pod := range node.BlockingPods {
+			reason += fmt.Sprintf("\n%s: %s", pod.Pod, pod.Reason)
+		}
+		return reason
+	default:
+		return ""
+	}
+}
+
+func printGroups(r *ClusterCompressionReview) {
+	if len(r.Status.GroupByLabel) == 0 {
+		return
+	}
+
+	t := table.NewWriter()
+	t.AppendHeader(table.Row{r.Status.GroupByLabel, "nodes", "scale down", "remaining", "cpu utilization", "memory utilization"})
+	for _, group := range r.Status.Groups {
+		name := group.Group
+		if len(name) == 0 {
+			name = "<none>"
+		}
+		t.AppendRow(table.Row{name, group.NodeCount, group.ScaleDownNodeCount, group.RemainingNodeCount,
+			fmt.Sprintf("%.2f%%", group.CPUUtilization*100), fmt.Sprintf("%.2f%%", group.MemoryUtilization*100)})
+	}
+	fmt.Printf("\nnode groups:\n%s\n", t.Render())
+}
@@ -4,17 +4,21 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
+	"sync"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/informers"
 	clientset "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
 
-	"github.com/k-cloud-labs/kluster-capacity/app/cmds/clustercompression/options"
 	"github.com/k-cloud-labs/kluster-capacity/pkg"
 	pkgframework "github.com/k-cloud-labs/kluster-capacity/pkg/framework"
+	pkgsimulator "github.com/k-cloud-labs/kluster-capacity/pkg/simulator"
 	"github.com/k-cloud-labs/kluster-capacity/pkg/utils"
 )
 
@@ -29,21 +33,62 @@ type simulator struct {
 	fakeClient               clientset.Interface
 	createdPods              []*corev1.Pod
 	createPodIndex           int
-	currentNode              string
 	currentNodeUnschedulable bool
 	bindSuccessPodCount      int
 	nodeFilter               NodeFilter
+	// nodes are selected and reported group by group if not empty
+	groupByLabel string
+	// loaded when the first node is selected
+	budgets *disruptionBudgets
+
+	// guards the fields below, they are updated by the scheduler and the informers while the report may be generated
+	// once the simulation is stopped by the timeout
+	lock sync.Mutex
+	// node being drained
+	currentNode string
+	// nodes could not be scaled down since evicting their pods violates PodDisruptionBudgets
+	blockedNodes []*BlockedNode
+	// outcome of each node simulated keyed by the node name
+	nodeReviews map[string]*NodeReview
+	// the simulation is deterministic if not zero
+	seed int64
 }
 
-// NewCCSimulatorExecutor create a ce simulator which is completely independent of apiserver so no need
-// for kubeconfig nor for apiserver url
-func NewCCSimulatorExecutor(conf *options.ClusterCompressionConfig) (pkg.Simulator, error) {
-	cc, err := utils.BuildKubeSchedulerCompletedConfig(conf.Options.SchedulerConfig, conf.Options.KubeConfig)
+// Config is the config of cc.
+type Config struct {
+	pkgsimulator.Options
+	// rules to select the nodes to scale down
+	FilterNodeOptions FilterNodeOptions
+	// label of nodes to group them, e.g. node pools, the nodes are compressed and reported group by group if not empty
+	GroupByLabel string
+	// rules to order the candidate nodes
+	NodeOrderOptions NodeOrderOptions
+	// custom order of the candidate nodes, it overrides NodeOrderOptions if not nil
+	NodeOrderer NodeOrderer
+}
+
+// Compress simulates scaling down the nodes one by one and reports the nodes could be scaled down.
+func Compress(ctx context.Context, conf *Config) (*ClusterCompressionReview, error) {
+	s, err := NewCCSimulatorExecutor(conf)
 	if err != nil {
 		return nil, err
 	}
 
-	kubeConfig, err := utils.BuildRestConfig(conf.Options.KubeConfig)
+	if err := s.Initialize(conf.InitObjs...); err != nil {
+		return nil, err
+	}
+
+	if err := s.Run(ctx); err != nil {
+		return nil, err
+	}
+
+	return s.Report().(*ClusterCompressionReview), nil
+}
+
+// NewCCSimulatorExecutor create a ce simulator which is completely independent of apiserver so no need
+// for kubeconfig nor for apiserver url
+func NewCCSimulatorExecutor(conf *Config) (pkg.Simulator, error) {
+	cc, kubeConfig, err := conf.BuildConfigs()
 	if err != nil {
 		return nil, err
 	}
@@ -52,7 +97,10 @@ func NewCCSimulatorExecutor(conf *options.ClusterCompressionConfig) (pkg.Simulat
 		simulated:           0,
 		bindSuccessPodCount: 0,
 		createPodIndex:      0,
-		maxSimulated:        conf.Options.MaxLimit,
+		maxSimulated:        conf.MaxLimit,
+		groupByLabel:        conf.GroupByLabel,
+		nodeReviews:         make(map[string]*NodeReview),
+		seed:                conf.Seed,
 	}
 
 	// add your custom event handlers
@@ -61,17 +109,22 @@ func NewCCSimulatorExecutor(conf *options.ClusterCompressionConfig) (pkg.Simulat
 		return nil, err
 	}
 
-	framework, err := pkgframework.NewKubeSchedulerFramework(cc, kubeConfig,
-		pkgframework.WithExcludeNodes(conf.Options.ExcludeNodes),
-		pkgframework.WithPostBindHook(s.postBindHook),
-	)
+	framework, err := conf.NewFramework(cc, kubeConfig, pkgframework.WithPostBindHook(s.postBindHook))
 	if err != nil {
 		return nil, err
 	}
 
 	s.Framework = framework
 	s.fakeClient = cc.Client
-	nodeFilter, err := NewNodeFilter(s.fakeClient, s.GetPodsByNode, conf.Options.ExcludeNodes, conf.Options.FilterNodeOptions)
+	orderer := conf.NodeOrderer
+	if orderer == nil {
+		orderer, err = NewNodeOrderer(conf.NodeOrderOptions, s.GetPodsByNode, conf.Seed)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	nodeFilter, err := NewNodeFilter(s.fakeClient, s.GetPodsByNode, s.hasDisruptionBudget, conf.ExcludeNodes, conf.FilterNodeOptions, conf.GroupByLabel, orderer)
 	if err != nil {
 		return nil, err
 	}
@@ -80,14 +133,157 @@ func NewCCSimulatorExecutor(conf *options.ClusterCompressionConfig) (pkg.Simulat
 	return s, nil
 }
 
-func (s *simulator) Run() error {
-	return s.Framework.Run(s.selectNextNode)
+func (s *simulator) Run(ctx context.Context) error {
+	return s.Framework.Run(ctx, s.selectNextNode)
 }
 
 func (s *simulator) Report() pkg.Printer {
 	klog.V(2).Infof("the following nodes can be offline to save resources: %v", s.Status().NodesToScaleDown)
 	klog.V(2).Infof("the clusterCompression StopReason: %s", s.Status().StopReason)
-	return generateReport(s.Status())
+	review := generateReport(s.Status())
+	if len(s.groupByLabel) > 0 {
+		groups, err := s.reportGroups()
+		if err != nil {
+			klog.ErrorS(err, "Failed to report groups")
+		}
+		review.Status.GroupByLabel = s.groupByLabel
+		review.Status.Groups = groups
+	}
+	s.lock.Lock()
+	review.Status.BlockedNodes = append([]*BlockedNode(nil), s.blockedNodes...)
+	s.lock.Unlock()
+	review.Status.BlockingPods = s.nodeFilter.BlockingPods()
+	nodes, err := s.reportNodes()
+	if err != nil {
+		klog.ErrorS(err, "Failed to report nodes")
+	}
+	review.Status.Nodes = nodes
+	review.Status.CreationTimestamp = utils.ReportTimestamp(s.seed)
+
+	return review
+}
+
+// reportGroups reports the nodes scaled down and the utilization of the remaining nodes of each group, nodes without
+// the label are grouped by the empty value.
+func (s *simulator) reportGroups() ([]*NodeGroupReview, error) {
+	nodes, err := s.fakeClient.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	scaledDown := sets.NewString(s.Status().NodesToScaleDown...)
+	groups := make(map[string]*NodeGroupReview)
+	// allocatable and requested cpu and memory of the remaining nodes of each group
+	allocatable := make(map[string]*framework.Resource)
+	requested := make(map[string]*framework.Resource)
+	for i := range nodes.Items {
+		node := &nodes.Items[i]
+		name := node.Labels[s.groupByLabel]
+		group, ok := groups[name]
+		if !ok {
+			group = &NodeGroupReview{Group: name, ScaleDownNodeNames: []string{}}
+			groups[name] = group
+			allocatable[name] = &framework.Resource{}
+			requested[name] = &framework.Resource{}
+		}
+
+		group.NodeCount++
+		if scaledDown.Has(node.Name) {
+			group.ScaleDownNodeNames = append(group.ScaleDownNodeNames, node.Name)
+			continue
+		}
+
+		group.RemainingNodeCount++
+		allocatable[name].Add(node.Status.Allocatable)
+		// no pods on the node is not an error
+		pods, _ := s.GetPodsByNode(node.Name)
+		for _, pod := range pods {
+			if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+				continue
+			}
+			request := utils.ComputePodResourceRequest(pod)
+			requested[name].MilliCPU += request.MilliCPU
+			requested[name].Memory += request.Memory
+		}
+	}
+
+	result := make([]*NodeGroupReview, 0, len(groups))
+	for name, group := range groups {
+		sort.Strings(group.ScaleDownNodeNames)
+		group.ScaleDownNodeCount = len(group.ScaleDownNodeNames)
+		if allocatable[name].MilliCPU > 0 {
+			group.CPUUtilization = float64(requested[name].MilliCPU) / float64(allocatable[name].MilliCPU)
+		}
+		if allocatable[name].Memory > 0 {
+			group.MemoryUtilization = float64(requested[name].Memory) / float64(allocatable[name].Memory)
+		}
+		result = append(result, group)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Group < result[j].Group
+	})
+
+	return result, nil
+}
+
+// reportNodes reports the outcome of every node, nodes which are neither simulated nor excluded by the last selection
+// are reported as not simulated, e.g. when the max limit is reached.
+func (s *simulator) reportNodes() ([]*NodeReview, error) {
+	nodes, err := s.fakeClient.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	excluded := s.nodeFilter.Excluded()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	result := make([]*NodeReview, 0, len(nodes.Items))
+	for i := range nodes.Items {
+		name := nodes.Items[i].Name
+		if review, ok := s.nodeReviews[name]; ok {
+			result = append(result, review)
+			continue
+		}
+
+		review := &NodeReview{NodeName: name, Outcome: NodeOutcomeNotSimulated}
+		// the node being drained is cordoned by cc itself, so it's excluded by its taint in the last selection
+		if name == s.currentNode {
+			review.Outcome = NodeOutcomeInterrupted
+		} else if status, ok := excluded[name]; ok {
+			review.Outcome = NodeOutcomeExcluded
+			review.FilterReason = status.ErrReason
+			review.BlockingPods = status.BlockingPods
+		}
+		result = append(result, review)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].NodeName < result[j].NodeName
+	})
+
+	return result, nil
+}
+
+func (s *simulator) getCurrentNode() string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.currentNode
+}
+
+func (s *simulator) setCurrentNode(name string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.currentNode = name
+}
+
+// setNodeReview records the outcome of a node simulated.
+func (s *simulator) setNodeReview(review *NodeReview) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.nodeReviews[review.NodeName] = review
 }
 
 func (s *simulator) postBindHook(bindPod *corev1.Pod) error {
@@ -99,16 +295,19 @@ func (s *simulator) postBindHook(bindPod *corev1.Pod) error {
 	s.bindSuccessPodCount++
 	if len(s.createdPods) > 0 && s.createPodIndex < len(s.createdPods) {
 		klog.V(2).Infof("create %d pod: %s", s.createPodIndex, s.createdPods[s.createPodIndex].Namespace+"/"+s.createdPods[s.createPodIndex].Name)
-		_, err := s.fakeClient.CoreV1().Pods(s.createdPods[s.createPodIndex].Namespace).Create(context.TODO(), utils.InitPod(s.createdPods[s.createPodIndex]), metav1.CreateOptions{})
+		err := s.CreatePod(utils.InitPod(s.createdPods[s.createPodIndex]))
 		if err != nil {
 			return err
 		}
 		s.createPodIndex++
 	} else if s.bindSuccessPodCount == len(s.createdPods) {
-		klog.V(2).Infof("add node %s to simulator status", s.currentNode)
-		s.UpdateNodesToScaleDown(s.currentNode)
+		currentNode := s.getCurrentNode()
+		klog.V(2).Infof("add node %s to simulator status", currentNode)
+		s.UpdateNodesToScaleDown(currentNode)
+		s.setNodeReview(&NodeReview{NodeName: currentNode, Outcome: NodeOutcomeScaledDown})
+		s.budgets.disrupt(s.createdPods)
 
-		err := s.addLabelToNode(s.currentNode, NodeScaledDownSuccessLabel, "true")
+		err := s.addLabelToNode(currentNode, NodeScaledDownSuccessLabel, "true")
 		if err != nil {
 			_ = s.Stop("FailedAddLabelToNode: " + err.Error())
 		}
@@ -125,7 +324,21 @@ func (s *simulator) postBindHook(bindPod *corev1.Pod) error {
 	return nil
 }
 
+// hasDisruptionBudget returns true if the pod is covered by a PodDisruptionBudget of the world.
+func (s *simulator) hasDisruptionBudget(pod *corev1.Pod) bool {
+	return s.budgets != nil && s.budgets.covers(pod)
+}
+
 func (s *simulator) selectNextNode() error {
+	// the budgets are needed by the node filter in cluster-autoscaler compatible mode
+	if s.budgets == nil {
+		budgets, err := newDisruptionBudgets(s.fakeClient)
+		if err != nil {
+			return err
+		}
+		s.budgets = budgets
+	}
+
 	s.Status().SelectNodeCountInc()
 	status := s.nodeFilter.SelectNode()
 	if status != nil && status.Node == nil {
@@ -134,35 +347,59 @@ func (s *simulator) selectNextNode() error {
 	node := status.Node
 	klog.V(2).Infof("select node %s to simulate\n", node.Name)
 
+	pods, err := s.podsToEvict(node)
+	if err != nil {
+		return err
+	}
+
+	if blocking := s.budgets.blocking(pods); len(blocking) > 0 {
+		klog.V(2).Infof("node %s is blocked by PodDisruptionBudgets %v\n", node.Name, blocking)
+		s.lock.Lock()
+		s.blockedNodes = append(s.blockedNodes, &BlockedNode{NodeName: node.Name, PodDisruptionBudgets: blocking})
+		s.lock.Unlock()
+		s.setNodeReview(&NodeReview{
+			NodeName:             node.Name,
+			Outcome:              NodeOutcomeBlocked,
+			PodDisruptionBudgets: blocking,
+		})
+		err := s.addLabelToNode(node.Name, NodeScaleDownBlockedLabel, "true")
+		if err != nil {
+			return err
+		}
+
+		return s.selectNextNode()
+	}
+
 	s.createdPods = nil
 	s.bindSuccessPodCount = 0
 	s.createPodIndex = 0
-	s.currentNode = node.Name
+	s.setCurrentNode(node.Name)
 	s.currentNodeUnschedulable = node.Spec.Unschedulable
 
-	err := s.cordon(node)
+	err = s.cordon(node)
 	if err != nil {
 		return err
 	}
 
-	err = s.deletePodsByNode(node)
+	err = s.deletePods(pods)
 	if err != nil {
 		return err
 	}
 	klog.V(2).Infof("node %s needs to create %d pods\n", node.Name, len(s.createdPods))
 
 	if len(s.createdPods) > 0 {
-		_, err = s.fakeClient.CoreV1().Pods(s.createdPods[s.createPodIndex].Namespace).Create(context.TODO(), utils.InitPod(s.createdPods[s.createPodIndex]), metav1.CreateOptions{})
+		err = s.CreatePod(utils.InitPod(s.createdPods[s.createPodIndex]))
 		klog.V(2).Infof("create %d pod: %s", s.createPodIndex, s.createdPods[s.createPodIndex].Namespace+"/"+s.createdPods[s.createPodIndex].Name)
 		if err != nil {
 			return err
 		}
 		s.createPodIndex++
 	} else {
-		klog.V(2).Infof("add node %s to simulator status", s.currentNode)
-		s.UpdateNodesToScaleDown(s.currentNode)
+		klog.V(2).Infof("add node %s to simulator status", node.Name)
+		s.UpdateNodesToScaleDown(node.Name)
+		s.setNodeReview(&NodeReview{NodeName: node.Name, Outcome: NodeOutcomeScaledDown})
 
-		err := s.addLabelToNode(s.currentNode, NodeScaledDownSuccessLabel, "true")
+		err := s.addLabelToNode(node.Name, NodeScaledDownSuccessLabel, "true")
 		if err != nil {
 			_ = s.Stop("FailedAddLabelToNode: " + err.Error())
 		}
@@ -268,24 +505,32 @@ func (s *simulator) updatePodsFromCreatedPods() error {
 	return nil
 }
 
-func (s *simulator) deletePodsByNode(node *corev1.Node) error {
+// podsToEvict returns the pods rescheduled when the node is scaled down, daemonset pods and terminating pods are kept.
+func (s *simulator) podsToEvict(node *corev1.Node) ([]*corev1.Pod, error) {
 	podList, err := s.getPodsByNode(node)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	var createdPods []*corev1.Pod
+	var pods []*corev1.Pod
 	for i := range podList {
 		if !utils.IsDaemonsetPod(podList[i].OwnerReferences) && podList[i].DeletionTimestamp == nil {
-			createdPods = append(createdPods, podList[i])
-			err := s.fakeClient.CoreV1().Pods(podList[i].Namespace).Delete(context.TODO(), podList[i].Name, metav1.DeleteOptions{})
-			if err != nil {
-				return err
-			}
+			pods = append(pods, podList[i])
+		}
+	}
+
+	return pods, nil
+}
+
+func (s *simulator) deletePods(pods []*corev1.Pod) error {
+	for i := range pods {
+		err := s.fakeClient.CoreV1().Pods(pods[i].Namespace).Delete(context.TODO(), pods[i].Name, metav1.DeleteOptions{})
+		if err != nil {
+			return err
 		}
 	}
 
-	s.createdPods = createdPods
+	s.createdPods = pods
 	return nil
 }
 
@@ -294,8 +539,7 @@ func (s *simulator) addEventHandlers(informerFactory informers.SharedInformerFac
 	_, _ = informerFactory.Core().V1().Pods().Informer().AddEventHandler(
 		cache.FilteringResourceEventHandler{
 			FilterFunc: func(obj interface{}) bool {
-				if pod, ok := obj.(*corev1.Pod); ok && pod.Spec.SchedulerName == pkg.SchedulerName &&
-					metav1.HasAnnotation(pod.ObjectMeta, pkg.PodProvisioner) {
+				if pod, ok := obj.(*corev1.Pod); ok && metav1.HasAnnotation(pod.ObjectMeta, pkg.PodProvisioner) {
 					return true
 				}
 				return false
@@ -312,19 +556,26 @@ func (s *simulator) addEventHandlers(informerFactory informers.SharedInformerFac
 								// 2. Uncordon this node if needed
 								// 3. Type the flags that cannot be filtered, clear the flags that prohibit scheduling, add failed scale down label, then selectNextNode
 								klog.V(2).Infof("Failed scheduling pod %s, reason: %s, message: %s\n", pod.Namespace+"/"+pod.Name, podCondition.Reason, podCondition.Message)
+								currentNode := s.getCurrentNode()
+								s.setNodeReview(&NodeReview{
+									NodeName:  currentNode,
+									Outcome:   NodeOutcomeFailed,
+									FailedPod: pod.Namespace + "/" + pod.Name,
+									Message:   podCondition.Message,
+								})
 								err = s.updatePodsFromCreatedPods()
 								if err != nil {
 									err = s.Stop("FailedDeletePodsFromCreatedPods: " + err.Error())
 								}
 
 								if !s.currentNodeUnschedulable {
-									err = s.unCordon(s.currentNode)
+									err = s.unCordon(currentNode)
 									if err != nil {
 										err = s.Stop("FailedUnCordon: " + err.Error())
 									}
 								}
 
-								err = s.addLabelToNode(s.currentNode, NodeScaledDownFailedLabel, "true")
+								err = s.addLabelToNode(currentNode, NodeScaledDownFailedLabel, "true")
 								if err != nil {
 									err = s.Stop("FailedAddLabelToNode: " + err.Error())
 								}
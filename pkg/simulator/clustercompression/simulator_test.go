@@ -0,0 +1,98 @@
+package clustercompression
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	pkgsimulator "github.com/k-cloud-labs/kluster-capacity/pkg/simulator"
+)
+
+func TestCompressReportsNodeInterrupted(t *testing.T) {
+	review, err := Compress(context.TODO(), &Config{
+		Options: pkgsimulator.Options{
+			InitObjs: newTestWorld(4),
+			MaxLimit: 1,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if review.Status.StopReason == nil || review.Status.StopReason.StopType != "LimitReached" {
+		t.Fatalf("expected stopped by the limit, got %+v", review.Status.StopReason)
+	}
+	if len(review.Status.ScaleDownNodeNames) != 1 {
+		t.Fatalf("expected 1 node scaled down, got %v", review.Status.ScaleDownNodeNames)
+	}
+
+	// the limit is checked once a pod of the next node is bound, so that node is being drained
+	outcomes := make(map[string][]string)
+	for _, node := range review.Status.Nodes {
+		outcomes[node.Outcome] = append(outcomes[node.Outcome], node.NodeName)
+	}
+	if nodes := outcomes[NodeOutcomeScaledDown]; len(nodes) != 1 || nodes[0] != review.Status.ScaleDownNodeNames[0] {
+		t.Errorf("expected %v scaled down, got %v", review.Status.ScaleDownNodeNames, outcomes)
+	}
+	if len(outcomes[NodeOutcomeInterrupted]) != 1 {
+		t.Errorf("expected 1 node interrupted, got %v", outcomes)
+	}
+	if len(outcomes[NodeOutcomeNotSimulated]) != 2 {
+		t.Errorf("expected 2 nodes not simulated, got %v", outcomes)
+	}
+}
+
+// newTestWorld returns nodes of 4 cpu, each of them runs a replicated pod of 1 cpu.
+func newTestWorld(numNodes int) []runtime.Object {
+	objs := []runtime.Object{
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: metav1.NamespaceDefault}},
+	}
+
+	allocatable := corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("4"),
+		corev1.ResourceMemory: resource.MustParse("8Gi"),
+		corev1.ResourcePods:   resource.MustParse("110"),
+	}
+	controller := true
+	for i := 0; i < numNodes; i++ {
+		name := fmt.Sprintf("node-%d", i)
+		objs = append(objs, &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{corev1.LabelHostname: name}},
+			Status: corev1.NodeStatus{
+				Capacity:    allocatable,
+				Allocatable: allocatable,
+				Conditions:  []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+			},
+		}, &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            fmt.Sprintf("pod-%d", i),
+				Namespace:       metav1.NamespaceDefault,
+				UID:             types.UID(fmt.Sprintf("uid-%d", i)),
+				OwnerReferences: []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "rs", UID: "rs", Controller: &controller}},
+			},
+			Spec: corev1.PodSpec{
+				NodeName:      name,
+				SchedulerName: corev1.DefaultSchedulerName,
+				Containers: []corev1.Container{{
+					Name:  "app",
+					Image: "nginx",
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse("1"),
+							corev1.ResourceMemory: resource.MustParse("1Gi"),
+						},
+					},
+				}},
+			},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		})
+	}
+
+	return objs
+}
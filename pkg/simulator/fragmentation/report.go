@@ -0,0 +1,145 @@
+package fragmentation
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/k-cloud-labs/kluster-capacity/pkg/utils"
+)
+
+type FragmentationReview struct {
+	metav1.TypeMeta
+	Spec   FragmentationReviewSpec   `json:"spec"`
+	Status FragmentationReviewStatus `json:"status"`
+}
+
+type FragmentationReviewSpec struct {
+	// the reference pod shapes
+	Templates []corev1.Pod `json:"templates"`
+}
+
+type FragmentationReviewStatus struct {
+	CreationTimestamp time.Time `json:"creationTimestamp"`
+	// fragmentation for each reference pod shape in the order of templates
+	Results []*FragmentationResult `json:"results"`
+	// problems which may affect the result
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+type FragmentationResult struct {
+	PodName string `json:"podName"`
+	// number of replicas ce could place
+	Replicas   int32       `json:"replicas"`
+	StopReason *StopReason `json:"stopReason"`
+	// cluster-wide fragmentation of cpu, memory and other resources requested by the pod
+	Resources []*ResourceFragmentation `json:"resources"`
+	// nodes contributing most stranded capacity in descending order
+	Nodes []*NodeFragmentation `json:"nodes"`
+}
+
+type StopReason struct {
+	StopType    string `json:"stopType"`
+	StopMessage string `json:"stopMessage"`
+}
+
+type ResourceFragmentation struct {
+	Resource corev1.ResourceName `json:"resource"`
+	// allocatable minus requests of pods bound to the nodes
+	Free resource.Quantity `json:"free"`
+	// requests of the replicas placed by ce
+	Placed resource.Quantity `json:"placed"`
+	// free capacity the pod could not use
+	Stranded resource.Quantity `json:"stranded"`
+	// stranded divided by free, 0 if nothing is free
+	Ratio float64 `json:"ratio"`
+}
+
+type NodeFragmentation struct {
+	NodeName  string                   `json:"nodeName"`
+	Replicas  int                      `json:"replicas"`
+	Resources []*ResourceFragmentation `json:"resources"`
+	// largest share of the cluster-wide stranded capacity of any resource
+	StrandedShare float64 `json:"strandedShare"`
+}
+
+func (r *FragmentationReview) Print(verbose bool, format string) error {
+	switch format {
+	case "json":
+		return utils.PrintJson(r)
+	case "yaml":
+		return utils.PrintYaml(r)
+	case "":
+		fragmentationReviewPrettyPrint(r, verbose)
+		return nil
+	default:
+		return fmt.Errorf("output format %q not recognized", format)
+	}
+}
+
+func fragmentationReviewPrettyPrint(r *FragmentationReview, verbose bool) {
+	for i, result := range r.Status.Results {
+		if i > 0 {
+			fmt.Println("---------------------------------------------------------------")
+		}
+
+		fmt.Printf("The cluster can schedule %v instance(s) of the pod %v.\n", result.Replicas, result.PodName)
+		if verbose && result.StopReason != nil {
+			fmt.Printf("\nTermination reason: %v: %v\n", result.StopReason.StopType, result.StopReason.StopMessage)
+		}
+
+		t := table.NewWriter()
+		t.AppendHeader(table.Row{"resource", "free", "placed", "stranded", "fragmentation"})
+		for _, rf := range result.Resources {
+			t.AppendRow(table.Row{rf.Resource, rf.Free.String(), rf.Placed.String(), rf.Stranded.String(), formatRatio(rf.Ratio)})
+		}
+		fmt.Printf("\n%s\n", t.Render())
+
+		if len(result.Nodes) == 0 {
+			continue
+		}
+
+		header := table.Row{"node", "replicas"}
+		for _, rf := range result.Resources {
+			header = append(header, fmt.Sprintf("stranded %s", rf.Resource))
+		}
+		header = append(header, "share")
+		t = table.NewWriter()
+		t.AppendHeader(header)
+		for _, node := range result.Nodes {
+			row := table.Row{node.NodeName, node.Replicas}
+			for _, rf := range node.Resources {
+				if verbose {
+					row = append(row, fmt.Sprintf("%s/%s", rf.Stranded.String(), rf.Free.String()))
+				} else {
+					row = append(row, rf.Stranded.String())
+				}
+			}
+			row = append(row, formatRatio(node.StrandedShare))
+			t.AppendRow(row)
+		}
+		fmt.Printf("\nNodes contributing most stranded capacity:\n%s\n", t.Render())
+	}
+
+	utils.PrintWarnings(r.Status.Warnings)
+}
+
+func formatRatio(ratio float64) string {
+	return fmt.Sprintf("%.2f%%", ratio*100)
+}
+
+// quantity formats v in the unit of the resource, cpu is in millicores.
+func quantity(name corev1.ResourceName, v int64) resource.Quantity {
+	switch name {
+	case corev1.ResourceCPU:
+		return *resource.NewMilliQuantity(v, resource.DecimalSI)
+	case corev1.ResourceMemory, corev1.ResourceEphemeralStorage:
+		return *resource.NewQuantity(v, resource.BinarySI)
+	default:
+		return *resource.NewQuantity(v, resource.DecimalSI)
+	}
+}
@@ -0,0 +1,250 @@
+package fragmentation
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+
+	"github.com/k-cloud-labs/kluster-capacity/pkg"
+	pkgframework "github.com/k-cloud-labs/kluster-capacity/pkg/framework"
+	pkgsimulator "github.com/k-cloud-labs/kluster-capacity/pkg/simulator"
+	"github.com/k-cloud-labs/kluster-capacity/pkg/simulator/capacityestimation"
+	"github.com/k-cloud-labs/kluster-capacity/pkg/utils"
+)
+
+// Config is the config of fa.
+type Config struct {
+	pkgsimulator.Options
+	// reference pod shapes, each of them is analyzed separately in the same world
+	Pods []*corev1.Pod
+	// max number of pod templates simulated at the same time, unlimited if not positive
+	Parallelism int
+	// max number of nodes reported for each pod, all nodes with stranded capacity are reported if not positive
+	TopNodes int
+}
+
+// Analyze reports how much free capacity could not be used by each reference pod. the free capacity of each node,
+// i.e. allocatable minus the requests of pods bound to it in the world ce starts from, is compared with the requests
+// of the replicas ce could place on it, and the rest is stranded. MaxLimit is ignored since the cluster must be filled up,
+// and an error is returned if ce is stopped by the timeout or interrupted before that.
+func Analyze(ctx context.Context, conf *Config) (*FragmentationReview, error) {
+	if err := conf.ValidateSource(); err != nil {
+		return nil, err
+	}
+
+	// the world is loaded by ce with the same rules, e.g. for terminating pods, pending pods and excluded nodes
+	world := pkgframework.NewWorld()
+	options := conf.Options
+	options.MaxLimit = 0
+	reviews, err := capacityestimation.Estimate(ctx, &capacityestimation.Config{
+		Options:     options,
+		Pods:        conf.Pods,
+		Parallelism: conf.Parallelism,
+		World:       world,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// the capacity not filled yet would be reported as stranded
+	for i, review := range reviews {
+		if review.Status.StopReason != nil && pkg.IsIncomplete(review.Status.StopReason.StopType) {
+			return nil, fmt.Errorf("ce of pod %s stopped before the cluster was filled up: %s", conf.Pods[i].Name, review.Status.StopReason.StopMessage)
+		}
+	}
+
+	free := freeResources(world.NodeInfos())
+
+	review := &FragmentationReview{
+		Spec: FragmentationReviewSpec{
+			Templates: make([]corev1.Pod, 0, len(conf.Pods)),
+		},
+		Status: FragmentationReviewStatus{
+			CreationTimestamp: utils.ReportTimestamp(conf.Seed),
+		},
+	}
+
+	warnings := sets.NewString()
+	for i, pod := range conf.Pods {
+		review.Spec.Templates = append(review.Spec.Templates, *pod.DeepCopy())
+		review.Status.Results = append(review.Status.Results, analyze(pod, reviews[i], free, conf.TopNodes))
+		for _, warning := range reviews[i].Status.Warnings {
+			if !warnings.Has(warning) {
+				warnings.Insert(warning)
+				review.Status.Warnings = append(review.Status.Warnings, warning)
+			}
+		}
+	}
+
+	return review, nil
+}
+
+// nodeResources is the free capacity of a node.
+type nodeResources struct {
+	name string
+	free *framework.Resource
+}
+
+// freeResources returns the free capacity of each node in the order of nodeInfos.
+func freeResources(nodeInfos []*framework.NodeInfo) []*nodeResources {
+	result := make([]*nodeResources, 0, len(nodeInfos))
+	for _, nodeInfo := range nodeInfos {
+		free := framework.NewResource(nodeInfo.Node().Status.Allocatable)
+		subResource(free, nodeInfo.Requested)
+		result = append(result, &nodeResources{name: nodeInfo.Node().Name, free: free})
+	}
+
+	return result
+}
+
+// analyze compares the free capacity with the requests of the replicas of pod placed by ce.
+func analyze(pod *corev1.Pod, review *capacityestimation.CapacityEstimationReview, free []*nodeResources, topNodes int) *FragmentationResult {
+	request := utils.ComputePodResourceRequest(pod)
+	names := resourceNames(request)
+
+	replicasOnNodes := make(map[string]int)
+	for _, result := range review.Status.Pods {
+		for _, replicas := range result.ReplicasOnNodes {
+			replicasOnNodes[replicas.NodeName] += replicas.Replicas
+		}
+	}
+
+	result := &FragmentationResult{
+		PodName:  pod.Name,
+		Replicas: review.Status.Replicas,
+	}
+	if review.Status.StopReason != nil {
+		result.StopReason = &StopReason{
+			StopType:    review.Status.StopReason.StopType,
+			StopMessage: review.Status.StopReason.StopMessage,
+		}
+	}
+
+	clusterFree := make([]int64, len(names))
+	clusterPlaced := make([]int64, len(names))
+	nodes := make([]*NodeFragmentation, 0, len(free))
+	nodeStranded := make([][]int64, 0, len(free))
+	for _, node := range free {
+		replicas := replicasOnNodes[node.name]
+		nodeResult := &NodeFragmentation{
+			NodeName: node.name,
+			Replicas: replicas,
+		}
+		stranded := make([]int64, len(names))
+		for i, name := range names {
+			nodeFree := nonNegative(value(node.free, name))
+			// placed could exceed free only if the world is changed, e.g. by preemption
+			placed := min64(int64(replicas)*value(request, name), nodeFree)
+			stranded[i] = nodeFree - placed
+			clusterFree[i] += nodeFree
+			clusterPlaced[i] += placed
+			nodeResult.Resources = append(nodeResult.Resources, newResourceFragmentation(name, nodeFree, placed))
+		}
+		nodes = append(nodes, nodeResult)
+		nodeStranded = append(nodeStranded, stranded)
+	}
+
+	for i, name := range names {
+		result.Resources = append(result.Resources, newResourceFragmentation(name, clusterFree[i], clusterPlaced[i]))
+	}
+
+	// the share of a node is its largest share of the stranded capacity of any resource, so that nodes stranding
+	// a scarce resource are reported even if they have few other resources left
+	for j, node := range nodes {
+		for i := range names {
+			if clusterStranded := clusterFree[i] - clusterPlaced[i]; clusterStranded > 0 {
+				if share := float64(nodeStranded[j][i]) / float64(clusterStranded); share > node.StrandedShare {
+					node.StrandedShare = share
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return nodes[i].StrandedShare > nodes[j].StrandedShare
+	})
+	for _, node := range nodes {
+		if node.StrandedShare <= 0 || topNodes > 0 && len(result.Nodes) >= topNodes {
+			break
+		}
+		result.Nodes = append(result.Nodes, node)
+	}
+
+	return result
+}
+
+// resourceNames returns cpu, memory and other resources requested by the pod in a stable order.
+func resourceNames(request *framework.Resource) []corev1.ResourceName {
+	names := []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory}
+	if request.EphemeralStorage > 0 {
+		names = append(names, corev1.ResourceEphemeralStorage)
+	}
+
+	scalars := make([]string, 0, len(request.ScalarResources))
+	for name, quantity := range request.ScalarResources {
+		if quantity > 0 {
+			scalars = append(scalars, string(name))
+		}
+	}
+	sort.Strings(scalars)
+	for _, name := range scalars {
+		names = append(names, corev1.ResourceName(name))
+	}
+
+	return names
+}
+
+func value(r *framework.Resource, name corev1.ResourceName) int64 {
+	switch name {
+	case corev1.ResourceCPU:
+		return r.MilliCPU
+	case corev1.ResourceMemory:
+		return r.Memory
+	case corev1.ResourceEphemeralStorage:
+		return r.EphemeralStorage
+	default:
+		return r.ScalarResources[name]
+	}
+}
+
+func subResource(r, delta *framework.Resource) {
+	r.MilliCPU -= delta.MilliCPU
+	r.Memory -= delta.Memory
+	r.EphemeralStorage -= delta.EphemeralStorage
+	for name, quantity := range delta.ScalarResources {
+		r.AddScalar(name, -quantity)
+	}
+}
+
+func nonNegative(v int64) int64 {
+	if v < 0 {
+		return 0
+	}
+
+	return v
+}
+
+func min64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+
+	return b
+}
+
+func newResourceFragmentation(name corev1.ResourceName, free, placed int64) *ResourceFragmentation {
+	r := &ResourceFragmentation{
+		Resource: name,
+		Free:     quantity(name, free),
+		Placed:   quantity(name, placed),
+		Stranded: quantity(name, free-placed),
+	}
+	if free > 0 {
+		r.Ratio = float64(free-placed) / float64(free)
+	}
+
+	return r
+}
@@ -0,0 +1,42 @@
+package simulator
+
+import (
+	"k8s.io/apimachinery/pkg/runtime"
+	restclient "k8s.io/client-go/rest"
+	"k8s.io/klog/v2"
+
+	"github.com/k-cloud-labs/kluster-capacity/pkg/framework"
+	"github.com/k-cloud-labs/kluster-capacity/pkg/snapshot"
+)
+
+// Loader loads the objects to initialize the world, the objects must be typed and are not modified.
+type Loader func() ([]runtime.Object, error)
+
+// ClusterLoader lists the world from the cluster specified by restConfig, only WithTolerant and the options scoping
+// the world take effect.
+func ClusterLoader(restConfig *restclient.Config, options ...framework.Option) Loader {
+	return func() ([]runtime.Object, error) {
+		objs, warnings, err := framework.GetInitObjects(restConfig, options...)
+		if err != nil {
+			return nil, err
+		}
+		for _, warning := range warnings {
+			klog.Warning(warning)
+		}
+
+		// converted by snapshot so that the objects are typed and managed fields are dropped
+		s, err := snapshot.New(objs)
+		if err != nil {
+			return nil, err
+		}
+
+		return s.TypedObjects()
+	}
+}
+
+// SnapshotLoader loads the world from the snapshot file, the file is read again on each refresh.
+func SnapshotLoader(path string) Loader {
+	return func() ([]runtime.Object, error) {
+		return snapshot.LoadObjects(path)
+	}
+}
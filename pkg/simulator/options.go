@@ -0,0 +1,122 @@
+package simulator
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	restclient "k8s.io/client-go/rest"
+	schedconfig "k8s.io/kubernetes/cmd/kube-scheduler/app/config"
+
+	"github.com/k-cloud-labs/kluster-capacity/pkg"
+	"github.com/k-cloud-labs/kluster-capacity/pkg/framework"
+	"github.com/k-cloud-labs/kluster-capacity/pkg/utils"
+)
+
+// Source is where the world is initialized from.
+type Source string
+
+const (
+	// SourceCluster copies the world from the cluster of KubeConfig
+	SourceCluster Source = "Cluster"
+	// SourceObjects initializes the world from InitObjs, e.g. loaded from a snapshot
+	SourceObjects Source = "Objects"
+)
+
+// Options are the options shared by all simulators, they are plain values so that the simulators could be used as
+// a library without the command line.
+type Options struct {
+	// where the world is initialized from. if empty, it's SourceObjects if InitObjs is not empty, otherwise
+	// SourceCluster. it should be set explicitly when InitObjs may be empty, e.g. loaded from a snapshot, so that an
+	// empty snapshot is never taken as the cluster
+	Source Source
+	// objects to initialize the world, e.g. loaded from a snapshot, only used if Source is SourceObjects
+	InitObjs []runtime.Object
+	// kubeconfig of the cluster to copy the world from, only used if Source is SourceCluster
+	KubeConfig string
+	// file of the scheduler configuration, the default configuration is used if empty
+	SchedulerConfig string
+	// scheduler framework used to simulate, one of kube-scheduler|volcano, kube-scheduler if empty
+	Framework    string
+	ExcludeNodes []string
+	// max number of pods or nodes simulated, unlimited if zero
+	MaxLimit int
+	// skip optional resources which are forbidden to list instead of failing
+	Tolerant bool
+	// scope of the simulated world, only matching nodes, pods bound to them and matching pending pods are loaded
+	NodeSelector labels.Selector
+	Namespace    string
+	PodSelector  labels.Selector
+	// records the scheduling decision of each pod if not nil
+	Tracer *framework.Tracer
+	// makes the simulation deterministic if not zero
+	Seed int64
+	// max time a pod waits for the other members of its gang with volcano framework, 10s if zero
+	GangWaitTime time.Duration
+	// extra options of the framework, e.g. framework.WithOutOfTreeRegistry to simulate with custom plugins
+	FrameworkOptions []framework.Option
+}
+
+// BuildConfigs builds the scheduler config and the config of the cluster, the latter is nil if the world is
+// initialized from InitObjs.
+func (o *Options) BuildConfigs() (*schedconfig.CompletedConfig, *restclient.Config, error) {
+	if err := o.ValidateSource(); err != nil {
+		return nil, nil, err
+	}
+
+	return utils.BuildConfigs(o.SchedulerConfig, o.KubeConfig, o.source() == SourceCluster)
+}
+
+// source returns Source, or the one inferred from InitObjs if it's not set.
+func (o *Options) source() Source {
+	if len(o.Source) > 0 {
+		return o.Source
+	}
+	if len(o.InitObjs) > 0 {
+		return SourceObjects
+	}
+	return SourceCluster
+}
+
+// ValidateSource checks whether the world could be initialized from Source.
+func (o *Options) ValidateSource() error {
+	switch o.source() {
+	case SourceCluster:
+		return nil
+	case SourceObjects:
+		if len(o.InitObjs) == 0 {
+			return errors.New("no objects to initialize the world, the snapshot may be empty")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported source %q, one of: %s|%s", o.Source, SourceCluster, SourceObjects)
+	}
+}
+
+// NewFramework creates the framework with the shared options, opts of the simulator are applied before
+// FrameworkOptions so that the latter could override them.
+func (o *Options) NewFramework(kubeSchedulerConfig *schedconfig.CompletedConfig, kubeConfig *restclient.Config, opts ...framework.Option) (pkg.Framework, error) {
+	var options []framework.Option
+	if o.NodeSelector != nil {
+		options = append(options, framework.WithNodeSelector(o.NodeSelector))
+	}
+	if len(o.Namespace) > 0 {
+		options = append(options, framework.WithNamespace(o.Namespace))
+	}
+	if o.PodSelector != nil {
+		options = append(options, framework.WithPodSelector(o.PodSelector))
+	}
+
+	options = append(options,
+		framework.WithExcludeNodes(o.ExcludeNodes),
+		framework.WithTolerant(o.Tolerant),
+		framework.WithTracer(o.Tracer),
+		framework.WithSeed(o.Seed),
+		framework.WithGangWaitTime(o.GangWaitTime))
+	options = append(options, opts...)
+	options = append(options, o.FrameworkOptions...)
+
+	return framework.New(o.Framework, kubeSchedulerConfig, kubeConfig, options...)
+}
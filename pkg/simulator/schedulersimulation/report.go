@@ -2,6 +2,7 @@ package schedulersimulation
 
 import (
 	"fmt"
+	"sort"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/kubernetes/pkg/scheduler/framework"
@@ -14,6 +15,7 @@ type SchedulerSimulationReview struct {
 	UnschedulablePods []corev1.Pod     `json:"unschedulablePods"`
 	Details           []ScheduleDetail `json:"details"`
 	StopReason        string           `json:"stopReason"`
+	Warnings          []string         `json:"warnings,omitempty"`
 }
 
 type ScheduleDetail struct {
@@ -39,7 +41,9 @@ func (r *SchedulerSimulationReview) Print(verbose bool, format string) error {
 }
 
 func prettyPrint(r *SchedulerSimulationReview, verbose bool) {
-	fmt.Printf("Termination reason: %s\n\n", r.StopReason)
+	fmt.Printf("Termination reason: %s\n", r.StopReason)
+	utils.PrintWarnings(r.Warnings)
+	fmt.Printf("\n")
 	if len(r.UnschedulablePods) > 0 {
 		fmt.Printf("Unschedulabel pods(%d):\n", len(r.UnschedulablePods))
 	}
@@ -123,10 +127,22 @@ func generateReport(status *pkg.Status) *SchedulerSimulationReview {
 		details = append(details, detail)
 	}
 
+	// map iteration is random, keep the report stable
+	sort.Slice(unschedulablePods, func(i, j int) bool {
+		if unschedulablePods[i].Namespace != unschedulablePods[j].Namespace {
+			return unschedulablePods[i].Namespace < unschedulablePods[j].Namespace
+		}
+		return unschedulablePods[i].Name < unschedulablePods[j].Name
+	})
+	sort.Slice(details, func(i, j int) bool {
+		return details[i].NodeName < details[j].NodeName
+	})
+
 	return &SchedulerSimulationReview{
 		UnschedulablePods: unschedulablePods,
 		Details:           details,
 		StopReason:        status.StopReason,
+		Warnings:          status.Warnings,
 	}
 }
 
@@ -1,49 +1,79 @@
 package schedulersimulation
 
 import (
+	"context"
 	"fmt"
 	"sync"
 
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/tools/cache"
 
-	"github.com/k-cloud-labs/kluster-capacity/app/cmds/schedulersimulation/options"
 	"github.com/k-cloud-labs/kluster-capacity/pkg"
 	"github.com/k-cloud-labs/kluster-capacity/pkg/framework"
-	"github.com/k-cloud-labs/kluster-capacity/pkg/utils"
+	pkgsimulator "github.com/k-cloud-labs/kluster-capacity/pkg/simulator"
 )
 
-type simulator struct {
-	pkg.Framework
+const (
+	ExitWhenAllScheduled = "AllScheduled"
+	ExitWhenAllSucceed   = "AllSucceed"
+)
 
-	exitCondition string
+// Config is the config of ss.
+type Config struct {
+	pkgsimulator.Options
+	// when to stop the simulation, one of AllScheduled|AllSucceed
+	ExitCondition string
+	// file to save the simulated world, nothing is saved if empty
+	SaveTo string
 }
 
-func NewSSSimulatorExecutor(conf *options.SchedulerSimulationConfig) (pkg.Simulator, error) {
-	kubeSchedulerConfig, err := utils.BuildKubeSchedulerCompletedConfig(conf.Options.SchedulerConfig, conf.Options.KubeConfig)
+// Simulate schedules all pending pods of the world and reports the result.
+func Simulate(ctx context.Context, conf *Config) (*SchedulerSimulationReview, error) {
+	s, err := NewSSSimulatorExecutor(conf)
 	if err != nil {
 		return nil, err
 	}
 
-	kubeConfig, err := utils.BuildRestConfig(conf.Options.KubeConfig)
+	if err := s.Initialize(conf.InitObjs...); err != nil {
+		return nil, err
+	}
+
+	if err := s.Run(ctx); err != nil {
+		return nil, err
+	}
+
+	return s.Report().(*SchedulerSimulationReview), nil
+}
+
+type simulator struct {
+	pkg.Framework
+
+	exitCondition string
+	// the simulation is deterministic if not zero
+	seed int64
+}
+
+func NewSSSimulatorExecutor(conf *Config) (pkg.Simulator, error) {
+	kubeSchedulerConfig, kubeConfig, err := conf.BuildConfigs()
 	if err != nil {
 		return nil, err
 	}
 
-	framework, err := framework.NewKubeSchedulerFramework(kubeSchedulerConfig, kubeConfig,
+	framework, err := conf.NewFramework(kubeSchedulerConfig, kubeConfig,
 		framework.WithNodeImages(false),
 		framework.WithScheduledPods(false),
 		framework.WithTerminatingPods(false),
-		framework.WithExcludeNodes(conf.Options.ExcludeNodes),
-		framework.WithSaveTo(conf.Options.SaveTo))
+		framework.WithSaveTo(conf.SaveTo))
 	if err != nil {
 		return nil, err
 	}
 
 	s := &simulator{
 		Framework:     framework,
-		exitCondition: conf.Options.ExitCondition,
+		exitCondition: conf.ExitCondition,
+		seed:          conf.Seed,
 	}
 
 	err = s.addEventHandlers(kubeSchedulerConfig.InformerFactory)
@@ -54,12 +84,25 @@ func NewSSSimulatorExecutor(conf *options.SchedulerSimulationConfig) (pkg.Simula
 	return s, nil
 }
 
-func (s *simulator) Run() error {
-	return s.Framework.Run(nil)
+func (s *simulator) Run(ctx context.Context) error {
+	return s.Framework.Run(ctx, nil)
 }
 
 func (s *simulator) Report() pkg.Printer {
-	return generateReport(s.Status())
+	review := generateReport(s.Status())
+	if s.seed != 0 {
+		// uid and timestamps of pods are different in each run, drop them so that seeded runs give identical reports
+		for i := range review.UnschedulablePods {
+			pod := &review.UnschedulablePods[i]
+			pod.UID = ""
+			for j := range pod.Status.Conditions {
+				pod.Status.Conditions[j].LastProbeTime = metav1.Time{}
+				pod.Status.Conditions[j].LastTransitionTime = metav1.Time{}
+			}
+		}
+	}
+
+	return review
 }
 
 func (s *simulator) addEventHandlers(informerFactory informers.SharedInformerFactory) (err error) {
@@ -108,10 +151,10 @@ func (s *simulator) addEventHandlers(informerFactory informers.SharedInformerFac
 				return true
 			})
 
-			if s.exitCondition == options.ExitWhenAllScheduled && succeedCount+failedCount == count {
+			if s.exitCondition == ExitWhenAllScheduled && succeedCount+failedCount == count {
 				stop = true
 				reason = "AllScheduled: %d pod(s) have been scheduled once."
-			} else if s.exitCondition == options.ExitWhenAllSucceed && succeedCount == count {
+			} else if s.exitCondition == ExitWhenAllSucceed && succeedCount == count {
 				stop = true
 				reason = "AllSucceed: %d pod(s) have been scheduled successfully."
 			}
@@ -0,0 +1,156 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+const (
+	// APIVersion is the version of the snapshot file format
+	APIVersion = "kluster-capacity.k-cloud-labs.io/v1alpha1"
+	// Kind is the kind of the snapshot file format
+	Kind = "Snapshot"
+)
+
+// Snapshot captures all objects used to initialize the world of the simulators,
+// so that a simulation can be reproduced later without a running cluster.
+type Snapshot struct {
+	metav1.TypeMeta   `json:",inline"`
+	CreationTimestamp metav1.Time `json:"creationTimestamp"`
+	// key is gk
+	Objects map[string][]*unstructured.Unstructured `json:"objects"`
+}
+
+// New creates a snapshot from objs, objs could be either typed or unstructured.
+func New(objs []runtime.Object) (*Snapshot, error) {
+	s := &Snapshot{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: APIVersion,
+			Kind:       Kind,
+		},
+		CreationTimestamp: metav1.NewTime(time.Now()),
+		Objects:           make(map[string][]*unstructured.Unstructured),
+	}
+
+	for _, obj := range objs {
+		u, err := toUnstructured(obj)
+		if err != nil {
+			return nil, err
+		}
+		// managed fields are useless for simulation but take a lot of space
+		u.SetManagedFields(nil)
+
+		gk := u.GroupVersionKind().GroupKind().String()
+		s.Objects[gk] = append(s.Objects[gk], u)
+	}
+
+	// keep the output stable for the same world
+	for _, items := range s.Objects {
+		sort.Slice(items, func(i, j int) bool {
+			if items[i].GetNamespace() != items[j].GetNamespace() {
+				return items[i].GetNamespace() < items[j].GetNamespace()
+			}
+			return items[i].GetName() < items[j].GetName()
+		})
+	}
+
+	return s, nil
+}
+
+// Load reads the snapshot from file and checks its version.
+func Load(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot file: %v", err)
+	}
+
+	s := &Snapshot{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot file: %v", err)
+	}
+
+	if s.Kind != Kind || s.APIVersion != APIVersion {
+		return nil, fmt.Errorf("unsupported snapshot %s, %s, only %s, %s is supported", s.APIVersion, s.Kind, APIVersion, Kind)
+	}
+
+	return s, nil
+}
+
+// LoadObjects reads the snapshot from file and returns typed objects in it.
+func LoadObjects(path string) ([]runtime.Object, error) {
+	s, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	objs, err := s.TypedObjects()
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode objects from snapshot: %v", err)
+	}
+
+	return objs, nil
+}
+
+// Save writes the snapshot to file.
+func (s *Snapshot) Save(path string) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot: %v", err)
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
+
+// TypedObjects converts all objects in the snapshot to typed objects which could be used to
+// initialize the world of the simulators, objects of kinds unknown to the client-go scheme, e.g. CRDs,
+// are kept unstructured.
+func (s *Snapshot) TypedObjects() ([]runtime.Object, error) {
+	var objs []runtime.Object
+	for _, items := range s.Objects {
+		for _, u := range items {
+			obj, err := scheme.Scheme.New(u.GroupVersionKind())
+			if err != nil {
+				if !runtime.IsNotRegisteredError(err) {
+					return nil, fmt.Errorf("unable to create object of %s: %v", u.GroupVersionKind().String(), err)
+				}
+				objs = append(objs, u.DeepCopy())
+				continue
+			}
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.UnstructuredContent(), obj); err != nil {
+				return nil, err
+			}
+			objs = append(objs, obj)
+		}
+	}
+
+	return objs, nil
+}
+
+func toUnstructured(obj runtime.Object) (*unstructured.Unstructured, error) {
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		return u.DeepCopy(), nil
+	}
+
+	gvks, _, err := scheme.Scheme.ObjectKinds(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	u := &unstructured.Unstructured{Object: content}
+	u.SetGroupVersionKind(gvks[0])
+
+	return u, nil
+}
@@ -12,6 +12,10 @@ type Status struct {
 	Nodes map[string]corev1.Node `json:"nodes"`
 	// for ce
 	PodsForEstimation []*corev1.Pod `json:"pods_for_estimation"`
+	// pods preempted by the pods for estimation, key is namespace/name of the preemptor
+	Victims map[string][]*corev1.Pod `json:"victims,omitempty"`
+	// pods preempted whose preemptor is unknown, e.g. it's not bound before the simulation stops
+	UnattributedVictims []*corev1.Pod `json:"unattributed_victims,omitempty"`
 	// for cc
 	NodesToScaleDown     []string `json:"nodes_to_scale_down"`
 	SelectNodeCount      int      `json:"select_node_count"`
@@ -19,6 +23,8 @@ type Status struct {
 	FailedSchedulerCount int      `json:"failed_scheduler_count"`
 	// stop reason
 	StopReason string `json:"stop_reason"`
+	// problems which don't stop the simulation but may affect the result, e.g. resources skipped
+	Warnings []string `json:"warnings,omitempty"`
 }
 
 func (s *Status) SelectNodeCountInc() {
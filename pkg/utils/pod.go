@@ -2,11 +2,17 @@ package utils
 
 import (
 	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
 
 	uuid "github.com/satori/go.uuid"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/yaml"
 	apiv1 "k8s.io/kubernetes/pkg/apis/core/v1"
 
 	"github.com/k-cloud-labs/kluster-capacity/pkg"
@@ -77,7 +83,6 @@ func InitPod(podTemplate *corev1.Pod) *corev1.Pod {
 
 	// reset pod
 	pod.Spec.NodeName = ""
-	pod.Spec.SchedulerName = pkg.SchedulerName
 	pod.Namespace = podTemplate.Namespace
 	if pod.Namespace == "" {
 		pod.Namespace = metav1.NamespaceDefault
@@ -96,3 +101,40 @@ func InitPod(podTemplate *corev1.Pod) *corev1.Pod {
 
 	return pod
 }
+
+// LoadPodTemplate loads the pod from template, which is either a path or a http(s) URL of a JSON or YAML file.
+func LoadPodTemplate(template string) (*corev1.Pod, error) {
+	var (
+		err          error
+		versionedPod = &corev1.Pod{}
+		spec         io.Reader
+	)
+
+	if strings.HasPrefix(template, "http://") || strings.HasPrefix(template, "https://") {
+		response, err := http.Get(template)
+		if err != nil {
+			return nil, err
+		}
+		defer response.Body.Close()
+		if response.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("unable to read URL %q, server reported %v, status code=%v", template, response.Status, response.StatusCode)
+		}
+		spec = response.Body
+	} else {
+		filename, _ := filepath.Abs(template)
+		f, err := os.Open(filename)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open config file: %v", err)
+		}
+		defer f.Close()
+		spec = f
+	}
+
+	decoder := yaml.NewYAMLOrJSONDecoder(spec, 4096)
+	err = decoder.Decode(versionedPod)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode config file: %v", err)
+	}
+
+	return versionedPod, nil
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/ghodss/yaml"
 	corev1 "k8s.io/api/core/v1"
@@ -60,6 +61,27 @@ func BuildRestConfig(config string) (*restclient.Config, error) {
 	}
 }
 
+// BuildConfigs builds the kube scheduler config and the rest config used to copy the world from the cluster,
+// the rest config is nil and no apiserver is needed if fromCluster is false.
+func BuildConfigs(schedulerConfig, kubeConfig string, fromCluster bool) (*schedconfig.CompletedConfig, *restclient.Config, error) {
+	if !fromCluster {
+		cc, err := BuildKubeSchedulerCompletedConfig(schedulerConfig, "")
+		return cc, nil, err
+	}
+
+	cc, err := BuildKubeSchedulerCompletedConfig(schedulerConfig, kubeConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	restConfig, err := BuildRestConfig(kubeConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cc, restConfig, nil
+}
+
 func BuildKubeSchedulerCompletedConfig(config, kubeconfig string) (*schedconfig.CompletedConfig, error) {
 	var kcfg *kubeschedulerconfig.KubeSchedulerConfiguration
 	if len(config) > 0 {
@@ -109,6 +131,27 @@ func PrintYaml(r pkg.Printer) error {
 	return nil
 }
 
+// ReportTimestamp returns the creation timestamp of a report, it's zero for seeded runs so that they give identical reports.
+func ReportTimestamp(seed int64) time.Time {
+	if seed != 0 {
+		return time.Time{}
+	}
+
+	return time.Now()
+}
+
+// PrintWarnings prints the warnings of a report as a list after a blank line, nothing is printed if there is no warning.
+func PrintWarnings(warnings []string) {
+	if len(warnings) == 0 {
+		return
+	}
+
+	fmt.Printf("\nWarnings:\n")
+	for _, warning := range warnings {
+		fmt.Printf("\t- %s\n", warning)
+	}
+}
+
 func ComputePodResourceRequest(pod *corev1.Pod) *framework.Resource {
 	result := &framework.Resource{}
 
@@ -143,13 +186,15 @@ func buildKubeSchedulerCompletedConfig(kcfg *kubeschedulerconfig.KubeSchedulerCo
 	// inject scheduler config
 	if len(kcfg.Profiles) == 0 {
 		kcfg.Profiles = []kubeschedulerconfig.KubeSchedulerProfile{
-			{},
+			{SchedulerName: corev1.DefaultSchedulerName},
 		}
 	}
 
-	kcfg.Profiles[0].SchedulerName = pkg.SchedulerName
-	if kcfg.Profiles[0].Plugins == nil {
-		kcfg.Profiles[0].Plugins = &kubeschedulerconfig.Plugins{}
+	// keep the name of all profiles so that pods are scheduled by the profile they really use
+	for i := range kcfg.Profiles {
+		if kcfg.Profiles[i].Plugins == nil {
+			kcfg.Profiles[i].Plugins = &kubeschedulerconfig.Plugins{}
+		}
 	}
 
 	opts := &kubescheduleroptions.Options{
@@ -157,6 +202,12 @@ func buildKubeSchedulerCompletedConfig(kcfg *kubeschedulerconfig.KubeSchedulerCo
 		Logs:            logs.NewOptions(),
 	}
 
+	// kubeconfig is empty when the world is initialized from snapshot, use a placeholder master to avoid
+	// falling back to in cluster config, the scheduler never talks to it since all clients are fake.
+	if len(kcfg.ClientConnection.Kubeconfig) == 0 {
+		opts.Master = "https://127.0.0.1"
+	}
+
 	c := &schedconfig.Config{}
 	// clear out all unnecessary options so no port is bound
 	// to allow running multiple instances in a row